@@ -0,0 +1,181 @@
+// Command zlogq reads, filters and pretty-prints zlog JSON log files.
+//
+//	zlogq -level warn -since 1h -where status>=500 logs/app.log
+//	zlogq -all -request-id 7f3a logs/app.log      # include rotated backups
+//	zlogq -f -trace-id 4bf92f35 logs/app.log      # follow like tail -f
+//	zlogq -time-key time -message-key message logs/app.log
+//
+// Without file arguments it reads standard input. The -*-key flags match
+// the keys of LoggerConfig.Encoding when the log renames them.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"github.com/chenzanhong/goutil/zlog/zlogq"
+)
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string     { return strings.Join(*s, ",") }
+func (s *stringsFlag) Set(v string) error { *s = append(*s, v); return nil }
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "zlogq:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		follow    bool
+		all       bool
+		raw       bool
+		noColor   bool
+		level     string
+		since     string
+		until     string
+		requestID string
+		traceID   string
+		where     stringsFlag
+		enc       zlog.EncodingConfig
+	)
+	flag.BoolVar(&follow, "f", false, "follow the file as it grows, like tail -f")
+	flag.BoolVar(&follow, "follow", false, "same as -f")
+	flag.BoolVar(&all, "all", false, "also read rotated and compressed backups, oldest first")
+	flag.BoolVar(&raw, "json", false, "print matching entries as the original JSON lines")
+	flag.BoolVar(&noColor, "no-color", false, "disable colors even on a terminal")
+	flag.StringVar(&level, "level", "", "minimum level: debug, info, warn, error, panic or fatal")
+	flag.StringVar(&since, "since", "", "show entries at or after this time (RFC 3339, 2006-01-02 15:04:05 or a duration such as 15m)")
+	flag.StringVar(&until, "until", "", "show entries before this time, same formats as -since")
+	flag.StringVar(&requestID, "request-id", "", "only entries with this request_id")
+	flag.StringVar(&traceID, "trace-id", "", "only entries with this trace_id")
+	flag.Var(&where, "where", "field expression such as status>=500, user.id=42, path~^/api/, error or !error (repeatable)")
+	flag.StringVar(&enc.TimeKey, "time-key", "", "key of the entry time (default \""+zlogq.TimeKey+"\"); - if entries have none")
+	flag.StringVar(&enc.LevelKey, "level-key", "", "key of the level (default \""+zlogq.LevelKey+"\"); - if entries have none")
+	flag.StringVar(&enc.NameKey, "logger-key", "", "key of the logger name (default \""+zlogq.LoggerKey+"\"); - if entries have none")
+	flag.StringVar(&enc.CallerKey, "caller-key", "", "key of the caller (default \""+zlogq.CallerKey+"\"); - if entries have none")
+	flag.StringVar(&enc.MessageKey, "message-key", "", "key of the message (default \""+zlogq.MessageKey+"\"); - if entries have none")
+	flag.StringVar(&enc.StacktraceKey, "stacktrace-key", "", "key of the stack trace (default \""+zlogq.StacktraceKey+"\"); - if entries have none")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: zlogq [flags] [file ...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	keys := zlogq.KeysFor(&enc)
+	filter := &zlogq.Filter{RequestID: requestID, TraceID: traceID, Keys: &keys}
+	if level != "" {
+		if err := filter.MinLevel.UnmarshalText([]byte(level)); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if since != "" {
+		t, err := zlogq.ParseTime(since, now)
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+	if until != "" {
+		t, err := zlogq.ParseTime(until, now)
+		if err != nil {
+			return err
+		}
+		filter.Until = t
+	}
+	for _, w := range where {
+		expr, err := zlogq.ParseFieldExpr(w)
+		if err != nil {
+			return err
+		}
+		filter.Where = append(filter.Where, expr)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	printer := &zlogq.Printer{W: out, Color: !noColor && zlog.IsTerminal(os.Stdout)}
+	emit := func(e *zlogq.Entry) error {
+		if raw {
+			out.Write(e.Raw)
+			return out.WriteByte('\n')
+		}
+		return printer.Print(e)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		if follow {
+			return fmt.Errorf("-f needs a file")
+		}
+		return zlogq.Scan(os.Stdin, filter, emit)
+	}
+
+	if !follow {
+		if all {
+			var err error
+			if paths, err = expand(paths); err != nil {
+				return err
+			}
+		}
+		return zlogq.ReadFiles(ctx, paths, filter, emit)
+	}
+
+	if len(paths) != 1 {
+		return fmt.Errorf("-f follows a single file")
+	}
+	if all {
+		backups, err := expand(paths)
+		if err != nil {
+			return err
+		}
+		// The live file, or the newest dated file of a time-rotated log, is
+		// read by Follow itself.
+		if n := len(backups); n > 0 && (backups[n-1] == paths[0] || !compressed(backups[n-1])) {
+			backups = backups[:n-1]
+		}
+		if err := zlogq.ReadFiles(ctx, backups, filter, emit); err != nil {
+			return err
+		}
+	}
+	// Flush after every entry so followed output appears immediately.
+	return zlogq.Follow(ctx, paths[0], all, filter, func(e *zlogq.Entry) error {
+		if err := emit(e); err != nil {
+			return err
+		}
+		return out.Flush()
+	})
+}
+
+// expand replaces each path with its rotated backups followed by itself.
+func expand(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		fs, err := zlogq.Files(p)
+		if err != nil {
+			return nil, err
+		}
+		if len(fs) == 0 {
+			return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+		}
+		files = append(files, fs...)
+	}
+	return files, nil
+}
+
+func compressed(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".zst")
+}
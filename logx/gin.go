@@ -0,0 +1,40 @@
+package logx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// GinLoggerConfig 返回写入 logger 的 gin.LoggerConfig，用于 gin.LoggerWithConfig
+// 每个请求记录为一条 level 级别的日志，时间由 logger 记录，因此格式中不再包含时间与颜色
+// skipPaths 中的路径不记录
+//
+//	r.Use(gin.LoggerWithConfig(logx.GinLoggerConfig(logger, zapcore.InfoLevel, "/healthz")))
+//	gin.DefaultErrorWriter = logx.GinWriter(logger, zapcore.ErrorLevel)
+func GinLoggerConfig(logger *zap.Logger, level zapcore.Level, skipPaths ...string) gin.LoggerConfig {
+	return gin.LoggerConfig{
+		Formatter: ginFormatter,
+		Output:    NewLineWriter(logger, level),
+		SkipPaths: skipPaths,
+	}
+}
+
+// GinWriter 返回以 level 级别写入 logger 的 gin 日志输出，
+// 可赋值给 gin.DefaultWriter、gin.DefaultErrorWriter 或 gin.LoggerConfig.Output
+func GinWriter(logger *zap.Logger, level zapcore.Level) *LineWriter {
+	return NewLineWriter(logger, level)
+}
+
+// ginFormatter 格式：状态码 耗时 客户端IP 方法 路径（含查询参数） 错误信息
+func ginFormatter(p gin.LogFormatterParams) string {
+	line := fmt.Sprintf("%d %v %s %s %s", p.StatusCode, p.Latency, p.ClientIP, p.Method, p.Path)
+	if msg := strings.TrimSpace(p.ErrorMessage); msg != "" {
+		// 多个错误以换行分隔，合并为一行以免拆成多条日志
+		line += " " + strings.ReplaceAll(msg, "\n", "; ")
+	}
+	return line + "\n"
+}
@@ -0,0 +1,145 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// GormConfig GORM 日志适配器配置
+type GormConfig struct {
+	LogLevel                  gormlogger.LogLevel // 默认 gormlogger.Warn；Info（包括 db.Debug()）时以 info 级别记录所有 SQL
+	SlowThreshold             time.Duration       // 超过该耗时的查询以 warn 级别记录，0 表示不检测
+	IgnoreRecordNotFoundError bool                // 不把 gorm.ErrRecordNotFound 记录为错误
+	RedactSQL                 bool                // 不展开查询参数，并把 SQL 中的字符串与数字字面量替换为 ?
+}
+
+// GormLogger 实现 gorm.io/gorm/logger.Interface，将 GORM 日志写入 zap.Logger
+// 调用者为业务代码中调用 GORM 的位置，ctx 中的请求 ID 与 trace ID 会一并记录
+//
+//	db, err := gorm.Open(dialector, &gorm.Config{
+//		Logger: logx.NewGormLogger(logger, logx.GormConfig{SlowThreshold: 200 * time.Millisecond}),
+//	})
+type GormLogger struct {
+	logger *zap.Logger
+	cfg    GormConfig
+}
+
+// NewGormLogger 创建 GORM 日志适配器
+func NewGormLogger(logger *zap.Logger, cfg GormConfig) *GormLogger {
+	if cfg.LogLevel == 0 {
+		cfg.LogLevel = gormlogger.Warn
+	}
+	return &GormLogger{logger: logger, cfg: cfg}
+}
+
+// LogMode 返回使用 level 的副本，对应 db.Debug() 等调用
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.cfg.LogLevel = level
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.LogLevel >= gormlogger.Info {
+		l.log(ctx, zapcore.InfoLevel, utils.CallerFrame(), fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.LogLevel >= gormlogger.Warn {
+		l.log(ctx, zapcore.WarnLevel, utils.CallerFrame(), fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.LogLevel >= gormlogger.Error {
+		l.log(ctx, zapcore.ErrorLevel, utils.CallerFrame(), fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 记录一次 SQL 执行：出错时为 error，慢查询为 warn，LogLevel 为 Info 时其余查询为 info
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.cfg.LogLevel <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	var (
+		lvl    zapcore.Level
+		msg    string
+		fields []zap.Field
+	)
+	switch {
+	case err != nil && l.cfg.LogLevel >= gormlogger.Error &&
+		(!errors.Is(err, gormlogger.ErrRecordNotFound) || !l.cfg.IgnoreRecordNotFoundError):
+		lvl, msg = zapcore.ErrorLevel, "gorm query failed"
+		fields = append(fields, zap.Error(err))
+	case l.cfg.SlowThreshold > 0 && elapsed > l.cfg.SlowThreshold && l.cfg.LogLevel >= gormlogger.Warn:
+		lvl, msg = zapcore.WarnLevel, "gorm slow query"
+		fields = append(fields, zap.Duration("threshold", l.cfg.SlowThreshold))
+	case l.cfg.LogLevel >= gormlogger.Info:
+		lvl, msg = zapcore.InfoLevel, "gorm query"
+	default:
+		return
+	}
+	if !l.logger.Core().Enabled(lvl) {
+		return
+	}
+
+	sql, rows := fc()
+	if l.cfg.RedactSQL {
+		sql = redactSQL(sql)
+	}
+	fields = append(fields, zap.String("sql", sql), zap.Duration("elapsed", elapsed))
+	if rows >= 0 {
+		fields = append(fields, zap.Int64("rows", rows))
+	}
+	l.log(ctx, lvl, utils.CallerFrame(), msg, fields...)
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，RedactSQL 时不把参数展开到 SQL 中
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.cfg.RedactSQL {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// log 以 frame 作为调用者写入一条日志
+func (l *GormLogger) log(ctx context.Context, lvl zapcore.Level, frame runtime.Frame, msg string, fields ...zap.Field) {
+	ce := l.logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+	if ce.Caller.Defined && frame.PC != 0 {
+		ce.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ce.Caller.Function = frame.Function
+	}
+	if ctx != nil {
+		fields = append(zlog.ContextFields(ctx), fields...)
+	}
+	ce.Write(fields...)
+}
+
+// sqlLiteral 匹配 SQL 中的字符串字面量（含连续两个单引号与 MySQL 反斜杠的转义）、$n 占位符与数字字面量
+var sqlLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+
+// redactSQL 将 SQL 中的字符串与数字字面量替换为 ?，保留 $n 占位符
+func redactSQL(sql string) string {
+	return sqlLiteral.ReplaceAllStringFunc(sql, func(lit string) string {
+		if strings.HasPrefix(lit, "$") {
+			return lit
+		}
+		return "?"
+	})
+}
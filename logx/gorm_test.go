@@ -0,0 +1,45 @@
+package logx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`SELECT * FROM users WHERE name = 'bob' AND age > 30`, `SELECT * FROM users WHERE name = ? AND age > ?`},
+		{`INSERT INTO t VALUES ('it''s', 1.5)`, `INSERT INTO t VALUES (?, ?)`},
+		{`INSERT INTO t VALUES ('it\'s', 'a\\', 'b')`, `INSERT INTO t VALUES (?, ?, ?)`},
+		{`UPDATE t SET v = $1 WHERE id = $2`, `UPDATE t SET v = $1 WHERE id = $2`},
+		{`SELECT col1 FROM t2`, `SELECT col1 FROM t2`},
+	}
+	for _, tt := range tests {
+		if got := redactSQL(tt.in); got != tt.want {
+			t.Errorf("redactSQL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGormLoggerInfoLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := NewGormLogger(zap.New(core), GormConfig{})
+	trace := func(gl gormlogger.Interface) {
+		gl.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	}
+
+	trace(l)
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("default Warn mode logged %d queries", n)
+	}
+	trace(l.LogMode(gormlogger.Info)) // what db.Debug() does
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].Level != zapcore.InfoLevel || entries[0].ContextMap()["sql"] != "SELECT 1" {
+		t.Fatalf("Info mode logged %v", entries)
+	}
+}
@@ -3,12 +3,9 @@
 package logx
 
 import (
-	"fmt"
-	"os"
-
+	"github.com/chenzanhong/goutil/zlog"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // RotateConfig 日志轮转配置
@@ -17,6 +14,12 @@ type RotateConfig struct {
 	MaxBackups int  // 备份数
 	MaxAge     int  // 天
 	Compress   bool // 是否压缩旧日志
+
+	// 按时间轮转与保留策略，任一字段非零时改用 zlog.RotatingWriter
+	Interval     string // 轮转周期：daily（按天）、hourly（按小时），为空时仅按大小轮转
+	Timezone     string // 计算零点所用的时区，如 Asia/Shanghai，默认本地时区
+	MaxTotalSize int    // 所有日志文件的总磁盘预算（MB），超出时删除最旧的文件
+	Compression  string // 旧日志压缩算法：gzip、zstd，在后台执行
 }
 
 // DefaultRotateConfig 默认轮转配置
@@ -30,75 +33,84 @@ var DefaultRotateConfig = RotateConfig{
 // IsZero 判断 RotateConfig 是否为零值
 // 如果所有字段都为零值，表示用户不想启用日志轮转
 func (r RotateConfig) IsZero() bool {
-	return r.MaxSize == 0 && r.MaxBackups == 0 && r.MaxAge == 0 && !r.Compress
+	return r.MaxSize == 0 && r.MaxBackups == 0 && r.MaxAge == 0 && !r.Compress && !r.timeBased()
+}
+
+// timeBased 判断是否使用了按时间轮转或磁盘预算等 lumberjack 不支持的选项
+func (r RotateConfig) timeBased() bool {
+	return r.Interval != "" || r.Timezone != "" || r.MaxTotalSize > 0 || r.Compression != ""
+}
+
+// rotationConfig 转换为 zlog.RotationConfig
+func (r RotateConfig) rotationConfig() zlog.RotationConfig {
+	compression := r.Compression
+	if compression == "" && r.Compress {
+		compression = zlog.CompressGzip
+	}
+	return zlog.RotationConfig{
+		Interval:     r.Interval,
+		Timezone:     r.Timezone,
+		MaxSize:      r.MaxSize,
+		MaxBackups:   r.MaxBackups,
+		MaxAge:       r.MaxAge,
+		MaxTotalSize: r.MaxTotalSize,
+		Compression:  compression,
+	}
+}
+
+// apply 将轮转配置写入 zlog.LoggerConfig
+// 零值表示不轮转，按时间轮转的选项使用 zlog.RotatingWriter，其余按大小轮转
+func (r RotateConfig) apply(cfg *zlog.LoggerConfig) {
+	cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge, cfg.Compress = r.MaxSize, r.MaxBackups, r.MaxAge, r.Compress
+	cfg.Rotation = nil
+	switch {
+	case r.IsZero():
+		cfg.Rotation = &zlog.RotationConfig{Interval: zlog.RotateNever}
+	case r.timeBased():
+		rc := r.rotationConfig()
+		cfg.Rotation = &rc
+	}
 }
 
-// SetupZapLogger 创建并返回一个 *zap.Logger
+// applyOutput 将轮转配置写入单个 zlog 文件输出，规则同 apply
+func (r RotateConfig) applyOutput(out *zlog.OutputConfig) {
+	compress := r.Compress
+	out.MaxSize, out.MaxBackups, out.MaxAge, out.Compress = r.MaxSize, r.MaxBackups, r.MaxAge, &compress
+	out.Rotation = nil
+	switch {
+	case r.IsZero():
+		out.Rotation = &zlog.RotationConfig{Interval: zlog.RotateNever}
+	case r.timeBased():
+		rc := r.rotationConfig()
+		out.Rotation = &rc
+	}
+}
+
+// SetupZapLogger 创建并返回一个写入 path 的 JSON 格式 *zap.Logger
 // 如果 rotate 为零值（RotateConfig{}），则不启用日志轮转，直接写入文件
-// 否则使用 lumberjack 进行日志轮转
+// 否则按大小或按时间轮转，与 zlog 的文件输出行为一致
+// 需要关闭日志文件时请使用 New
 func SetupZapLogger(
 	path string,
 	level zapcore.Level,
 	rotate RotateConfig,
 	addCaller, addStacktrace bool,
 ) (*zap.Logger, error) {
-	if err := checkFileWritable(path); err != nil {
-		return nil, fmt.Errorf("无法访问日志文件 %s: %v", path, err)
+	opts := []Option{
+		WithFile(path),
+		WithLevel(level),
+		WithFormat(zlog.FormatJSON),
+		WithRotate(rotate),
+		WithCaller(addCaller),
 	}
-
-	// 决定日志写入方式
-	var writeSyncer zapcore.WriteSyncer
-	if rotate.IsZero() {
-		// 不启用轮转：直接写入文件
-		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			return nil, fmt.Errorf("无法打开日志文件 %s: %v", path, err)
-		}
-		writeSyncer = zapcore.AddSync(file)
-	} else {
-		// 启用轮转：使用 lumberjack
-		lumberjackLogger := &lumberjack.Logger{
-			Filename:   path,
-			MaxSize:    rotate.MaxSize,
-			MaxBackups: rotate.MaxBackups,
-			MaxAge:     rotate.MaxAge,
-			Compress:   rotate.Compress,
-		}
-		writeSyncer = zapcore.AddSync(lumberjackLogger)
+	if !addStacktrace {
+		opts = append(opts, WithoutStacktrace())
 	}
-
-	// 编码器配置
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoder := zapcore.NewJSONEncoder(encoderConfig)
-
-	// 核心
-	core := zapcore.NewCore(encoder, writeSyncer, level)
-
-	// 构建选项
-	var options []zap.Option
-	if addCaller {
-		options = append(options, zap.AddCaller())
-	}
-	if addStacktrace {
-		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
-	}
-
-	logger := zap.New(core, options...)
-	return logger, nil
+	logger, _, err := New(opts...)
+	return logger, err
 }
 
 // 快捷函数：使用默认配置（启用轮转）
 func SetupDefaultZapLogger(path string) (*zap.Logger, error) {
 	return SetupZapLogger(path, zapcore.InfoLevel, DefaultRotateConfig, true, false)
 }
-
-// checkFileWritable 检查路径是否可写（用于早期验证）
-func checkFileWritable(path string) error {
-	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return err
-	}
-	file.Close()
-	return nil
-}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package logx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSetupZapLoggerRotate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rotate RotateConfig
+		files  int
+	}{
+		{"zero", RotateConfig{}, 1},
+		{"size", RotateConfig{MaxSize: 1, MaxBackups: 5}, 2},
+	}
+	msg := strings.Repeat("x", 1000)
+	for _, tt := range tests {
+		dir := t.TempDir()
+		logger, err := SetupZapLogger(filepath.Join(dir, "app.log"), zapcore.InfoLevel, tt.rotate, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1500; i++ { // 约 1.5MB
+			logger.Info(msg)
+		}
+		logger.Sync()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != tt.files {
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			t.Errorf("%s: files %v, want %d", tt.name, names, tt.files)
+		}
+	}
+}
+
+func TestSetupZapLoggerOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, err := SetupZapLogger(path, zapcore.WarnLevel, RotateConfig{}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("dropped")
+	logger.Error("kept")
+	logger.Sync()
+
+	lines := readLines(t, path)
+	if len(lines) != 1 || lines[0]["msg"] != "kept" {
+		t.Fatalf("file = %v", lines)
+	}
+	if caller, _ := lines[0]["caller"].(string); !strings.HasPrefix(caller, "logx/log_test.go:") {
+		t.Errorf("caller = %v", lines[0]["caller"])
+	}
+	if _, ok := lines[0]["stacktrace"]; ok {
+		t.Error("stacktrace written with addStacktrace false")
+	}
+}
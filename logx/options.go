@@ -0,0 +1,229 @@
+package logx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Option 配置 New 创建的日志记录器
+type Option func(*options)
+
+type options struct {
+	cfg        zlog.LoggerConfig
+	rotate     *RotateConfig // 为 nil 时使用 cfg 中的轮转设置
+	dirMode    os.FileMode
+	files      []LevelFile // NewSplit 的分级文件，为空时使用 DefaultLevelFiles
+	combined   *LevelFile  // NewSplit 的合并文件
+	caller     bool
+	stacktrace zapcore.LevelEnabler // 为 nil 时不记录堆栈
+}
+
+// WithConfig 以 zlog.LoggerConfig 为基础配置，其后的选项会覆盖对应字段
+func WithConfig(cfg zlog.LoggerConfig) Option {
+	return func(o *options) {
+		o.cfg = cfg
+	}
+}
+
+// WithLevel 设置日志级别，默认 info；DPanicLevel 按 error 处理
+func WithLevel(level zapcore.Level) Option {
+	return func(o *options) {
+		o.cfg.Level = zlogLevel(level)
+	}
+}
+
+// WithOutput 设置输出目标：console、file、both
+// 未设置时，配置了文件路径则写文件，否则写控制台
+func WithOutput(output string) Option {
+	return func(o *options) {
+		o.cfg.Output = output
+	}
+}
+
+// WithFile 设置日志文件路径
+func WithFile(path string) Option {
+	return func(o *options) {
+		o.cfg.FilePath = path
+	}
+}
+
+// WithFormat 设置编码格式：json、console、logfmt，默认 json
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.cfg.Format = format
+	}
+}
+
+// WithRotate 设置日志文件轮转，零值 RotateConfig{} 表示不轮转
+// 未设置时使用 DefaultRotateConfig
+func WithRotate(rotate RotateConfig) Option {
+	return func(o *options) {
+		o.rotate = &rotate
+	}
+}
+
+// WithDirMode 设置自动创建日志目录时使用的权限，默认 0755
+func WithDirMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.dirMode = mode
+	}
+}
+
+// WithFields 为每条日志添加固定字段，可多次调用
+func WithFields(fields map[string]string) Option {
+	return func(o *options) {
+		for k, v := range fields {
+			WithField(k, v)(o)
+		}
+	}
+}
+
+// WithField 为每条日志添加一个固定字段
+func WithField(key, value string) Option {
+	return func(o *options) {
+		fields := make(map[string]string, len(o.cfg.Fields)+1)
+		for k, v := range o.cfg.Fields {
+			fields[k] = v
+		}
+		fields[key] = value
+		o.cfg.Fields = fields
+	}
+}
+
+// WithSampling 启用日志采样，cfg 为 nil 时使用 zlog 的默认采样参数
+func WithSampling(cfg *zlog.SamplingConfig) Option {
+	return func(o *options) {
+		o.cfg.Sampling = true
+		o.cfg.Sampler = cfg
+	}
+}
+
+// WithCaller 设置是否记录调用者信息，默认记录
+func WithCaller(enabled bool) Option {
+	return func(o *options) {
+		o.caller = enabled
+	}
+}
+
+// WithStacktrace 设置记录堆栈的最低级别，默认 error
+func WithStacktrace(level zapcore.Level) Option {
+	return func(o *options) {
+		o.stacktrace = level
+	}
+}
+
+// WithoutStacktrace 不记录堆栈
+func WithoutStacktrace() Option {
+	return func(o *options) {
+		o.stacktrace = nil
+	}
+}
+
+// New 根据选项创建日志记录器，返回的 closer 会同步并关闭日志文件
+// 输出、编码与轮转均由 zlog 实现，与 zlog.New 的行为一致
+//
+//	logger, closer, err := logx.New(
+//		logx.WithFile("./logs/app.log"),
+//		logx.WithOutput("both"),
+//		logx.WithField("service", "payment"),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer closer()
+func New(opts ...Option) (*zap.Logger, func() error, error) {
+	o := newOptions(opts)
+	cfg := o.cfg
+	if cfg.Output == "" {
+		cfg.Output = "console"
+		if cfg.FilePath != "" {
+			cfg.Output = "file"
+		}
+	}
+	if rotate := o.rotateConfig(); rotate != nil {
+		rotate.apply(&cfg)
+	}
+	if cfg.FilePath != "" && cfg.Output != "console" {
+		if err := o.mkdir(filepath.Dir(cfg.FilePath)); err != nil {
+			return nil, nil, err
+		}
+	}
+	return o.build(cfg)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		cfg: zlog.LoggerConfig{
+			Level:  zlog.InfoLevel,
+			Format: zlog.FormatJSON,
+		},
+		dirMode:    0755,
+		caller:     true,
+		stacktrace: zapcore.ErrorLevel,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// rotateConfig 返回 WithRotate 的设置，都未设置时返回 DefaultRotateConfig
+// 基础配置中已有轮转设置时返回 nil
+func (o *options) rotateConfig() *RotateConfig {
+	if o.rotate == nil && o.cfg.MaxSize == 0 && o.cfg.Rotation == nil {
+		rotate := DefaultRotateConfig
+		return &rotate
+	}
+	return o.rotate
+}
+
+// mkdir 以 WithDirMode 设置的权限创建日志目录
+func (o *options) mkdir(dir string) error {
+	if err := os.MkdirAll(dir, o.dirMode); err != nil {
+		return fmt.Errorf("创建日志目录 %s 失败: %v", dir, err)
+	}
+	return nil
+}
+
+// build 通过 zlog 创建日志记录器，并应用调用者与堆栈选项
+func (o *options) build(cfg zlog.LoggerConfig) (*zap.Logger, func() error, error) {
+	l, err := zlog.New(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建日志记录器失败: %w", err)
+	}
+
+	var zapOpts []zap.Option
+	if !o.caller {
+		zapOpts = append(zapOpts, zap.WithCaller(false))
+	}
+	if o.stacktrace != nil {
+		zapOpts = append(zapOpts, zap.AddStacktrace(o.stacktrace))
+	} else {
+		zapOpts = append(zapOpts, zap.AddStacktrace(zap.LevelEnablerFunc(func(zapcore.Level) bool { return false })))
+	}
+	return l.Zap().WithOptions(zapOpts...), l.Close, nil
+}
+
+// zlogLevel 将 zapcore.Level 转换为 zlog.Level
+// zlog 没有 dpanic 级别，DPanicLevel 映射为 error，以免丢弃 DPanic 日志
+func zlogLevel(level zapcore.Level) zlog.Level {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return zlog.DebugLevel
+	case level == zapcore.InfoLevel:
+		return zlog.InfoLevel
+	case level == zapcore.WarnLevel:
+		return zlog.WarnLevel
+	case level == zapcore.ErrorLevel, level == zapcore.DPanicLevel:
+		return zlog.ErrorLevel
+	case level < zapcore.FatalLevel:
+		return zlog.PanicLevel
+	default:
+		return zlog.FatalLevel
+	}
+}
@@ -0,0 +1,202 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// captureStdout 在 fn 执行期间捕获标准输出，New 创建控制台输出时会记住当时的 os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- data
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+// readLines 读取 JSON 日志文件中的每一行，文件不存在时返回 nil
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []map[string]interface{}
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestNewOutput(t *testing.T) {
+	tests := []struct {
+		output        string
+		withFile      bool
+		file, console bool
+	}{
+		{"", false, false, true},
+		{"", true, true, false},
+		{"console", true, false, true},
+		{"file", true, true, false},
+		{"both", true, true, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "logs", "app.log")
+		opts := []Option{WithOutput(tt.output)}
+		if tt.withFile {
+			opts = append(opts, WithFile(path))
+		}
+		out := captureStdout(t, func() {
+			logger, closer, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			logger.Info("hello")
+			_ = closer() // 管道不支持 Sync
+		})
+		if got := strings.Contains(out, "hello"); got != tt.console {
+			t.Errorf("output %q, file %v: console output = %q", tt.output, tt.withFile, out)
+		}
+		if got := len(readLines(t, path)) == 1; got != tt.file {
+			t.Errorf("output %q, file %v: file written = %v, want %v", tt.output, tt.withFile, got, tt.file)
+		}
+	}
+}
+
+func TestNewFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := New(
+		WithFile(path),
+		WithField("service", "payment"),
+		WithFields(map[string]string{"env": "prod", "region": "cn"}),
+		WithField("env", "staging"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("one")
+	logger.With(zap.String("order", "42")).Warn("two")
+	closer()
+
+	lines := readLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if line["service"] != "payment" || line["env"] != "staging" || line["region"] != "cn" {
+			t.Errorf("static fields missing: %v", line)
+		}
+	}
+	if lines[1]["order"] != "42" {
+		t.Errorf("With field missing: %v", lines[1])
+	}
+}
+
+// openFiles 返回本进程打开 path 的文件描述符数量
+func openFiles(t *testing.T, path string) int {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("/proc/self/fd 不可用")
+	}
+	n := 0
+	for _, e := range entries {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name())); err == nil && target == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNewCloserClosesFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := New(WithFile(path), WithRotate(RotateConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("before close")
+	if openFiles(t, path) != 1 {
+		t.Fatalf("%s is not open", path)
+	}
+	if err := closer(); err != nil {
+		t.Fatal(err)
+	}
+	if n := openFiles(t, path); n != 0 {
+		t.Errorf("%d descriptors of %s still open after closer", n, path)
+	}
+	if lines := readLines(t, path); len(lines) != 1 || lines[0]["msg"] != "before close" {
+		t.Errorf("file = %v", lines)
+	}
+}
+
+func TestNewStacktrace(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"default", nil, true},
+		{"WithoutStacktrace", []Option{WithoutStacktrace()}, false},
+		{"WithStacktrace(warn)", []Option{WithStacktrace(zapcore.WarnLevel)}, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "app.log")
+		logger, closer, err := New(append([]Option{WithFile(path)}, tt.opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Error("failed")
+		closer()
+		lines := readLines(t, path)
+		if len(lines) != 1 {
+			t.Fatalf("%s: got %d lines", tt.name, len(lines))
+		}
+		if _, ok := lines[0]["stacktrace"]; ok != tt.want {
+			t.Errorf("%s: stacktrace present = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
+
+func TestZlogLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger, closer, err := New(WithFile(path), WithLevel(zapcore.DPanicLevel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Warn("warn")
+	logger.DPanic("dpanic")
+	closer()
+	lines := readLines(t, path)
+	if len(lines) != 1 || lines[0]["msg"] != "dpanic" {
+		t.Errorf("DPanicLevel logger wrote %v, want only the DPanic entry", lines)
+	}
+}
@@ -0,0 +1,129 @@
+package logx
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelFile 描述 NewSplit 中的一个日志文件，写入 [MinLevel, MaxLevel] 范围内的日志
+type LevelFile struct {
+	Name     string        // 相对基础目录的文件名
+	MinLevel zapcore.Level // 最低级别，低于日志级别时以日志级别为准
+	MaxLevel zapcore.Level // 最高级别
+	Rotate   *RotateConfig // 该文件的轮转配置，为 nil 时使用 WithRotate 的设置
+}
+
+// DefaultLevelFiles 默认的分级文件：info.log 记录 debug 与 info，warn.log 记录 warn，error.log 记录 error 及以上
+var DefaultLevelFiles = []LevelFile{
+	{Name: "info.log", MinLevel: zapcore.DebugLevel, MaxLevel: zapcore.InfoLevel},
+	{Name: "warn.log", MinLevel: zapcore.WarnLevel, MaxLevel: zapcore.WarnLevel},
+	{Name: "error.log", MinLevel: zapcore.ErrorLevel, MaxLevel: zapcore.FatalLevel},
+}
+
+// WithLevelFiles 设置 NewSplit 的分级文件，替换 DefaultLevelFiles
+func WithLevelFiles(files ...LevelFile) Option {
+	return func(o *options) {
+		o.files = append([]LevelFile(nil), files...)
+	}
+}
+
+// WithCombinedFile 让 NewSplit 额外把所有级别写入 name，rotate 为 nil 时使用 WithRotate 的设置
+func WithCombinedFile(name string, rotate *RotateConfig) Option {
+	return func(o *options) {
+		o.combined = &LevelFile{
+			Name:     name,
+			MinLevel: zapcore.DebugLevel,
+			MaxLevel: zapcore.FatalLevel,
+			Rotate:   rotate,
+		}
+	}
+}
+
+// NewSplit 创建按级别拆分文件的日志记录器，所有文件位于 dir 下
+// dir 不存在时以 WithDirMode 设置的权限创建；WithOutput("both") 时同时输出到控制台
+// 返回的 closer 会同步并关闭所有日志文件
+//
+//	logger, closer, err := logx.NewSplit("./logs",
+//		logx.WithCombinedFile("all.log", nil),
+//		logx.WithDirMode(0750),
+//	)
+func NewSplit(dir string, opts ...Option) (*zap.Logger, func() error, error) {
+	if dir == "" {
+		return nil, nil, fmt.Errorf("日志目录不能为空")
+	}
+	o := newOptions(opts)
+	files := o.files
+	if len(files) == 0 {
+		files = DefaultLevelFiles
+	}
+	if o.combined != nil {
+		files = append(files[:len(files):len(files)], *o.combined)
+	}
+	if err := o.mkdir(dir); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := o.cfg
+	cfg.Rotation = nil
+	cfg.Outputs = nil
+	switch cfg.Output {
+	case "console", "both":
+		cfg.Outputs = append(cfg.Outputs, zlog.OutputConfig{Type: zlog.OutputStdout, Format: cfg.Format})
+	}
+	for _, f := range files {
+		out, ok, err := o.fileOutput(dir, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			cfg.Outputs = append(cfg.Outputs, out)
+		}
+	}
+	if len(cfg.Outputs) == 0 {
+		return nil, nil, fmt.Errorf("日志级别 %s 高于所有分级文件的最高级别", cfg.Level)
+	}
+	return o.build(cfg)
+}
+
+// fileOutput 将 LevelFile 转换为 zlog 文件输出，级别范围低于日志级别时返回 false
+func (o *options) fileOutput(dir string, f LevelFile) (zlog.OutputConfig, bool, error) {
+	if f.Name == "" {
+		return zlog.OutputConfig{}, false, fmt.Errorf("分级文件名不能为空")
+	}
+	if f.MaxLevel < f.MinLevel {
+		return zlog.OutputConfig{}, false, fmt.Errorf("分级文件 %s 的最高级别 %s 低于最低级别 %s", f.Name, f.MaxLevel, f.MinLevel)
+	}
+	minLevel, maxLevel := zlogLevel(f.MinLevel), zlogLevel(f.MaxLevel)
+	if lvl := o.cfg.Level; lvl.Severity() > maxLevel.Severity() {
+		return zlog.OutputConfig{}, false, nil
+	} else if lvl.Severity() > minLevel.Severity() {
+		minLevel = lvl
+	}
+
+	path := filepath.Join(dir, f.Name)
+	if err := o.mkdir(filepath.Dir(path)); err != nil {
+		return zlog.OutputConfig{}, false, err
+	}
+	out := zlog.OutputConfig{
+		Type:     zlog.OutputFile,
+		Level:    minLevel,
+		MaxLevel: maxLevel,
+		Format:   o.cfg.Format,
+		Path:     path,
+	}
+	rotate := f.Rotate
+	if rotate == nil {
+		rotate = o.rotateConfig()
+	}
+	if rotate != nil {
+		rotate.applyOutput(&out)
+	} else if o.cfg.Rotation != nil {
+		rc := *o.cfg.Rotation
+		out.Rotation = &rc
+	}
+	return out, true, nil
+}
@@ -0,0 +1,140 @@
+package logx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// messagesIn 返回 JSON 日志文件中的消息，以空格分隔
+func messagesIn(t *testing.T, path string) string {
+	t.Helper()
+	var msgs []string
+	for _, line := range readLines(t, path) {
+		msgs = append(msgs, line["msg"].(string))
+	}
+	return strings.Join(msgs, " ")
+}
+
+func TestNewSplitLevelFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []Option
+		files map[string]string // 文件名到消息，空字符串表示文件不存在
+	}{
+		{"default", []Option{WithLevel(zapcore.DebugLevel)}, map[string]string{
+			"info.log": "debug info", "warn.log": "warn", "error.log": "error dpanic",
+		}},
+		{"combined", []Option{WithLevel(zapcore.DebugLevel), WithCombinedFile("all.log", nil)}, map[string]string{
+			"info.log": "debug info", "warn.log": "warn", "error.log": "error dpanic",
+			"all.log": "debug info warn error dpanic",
+		}},
+		{"level above band", []Option{WithLevel(zapcore.WarnLevel)}, map[string]string{
+			"info.log": "", "warn.log": "warn", "error.log": "error dpanic",
+		}},
+		{"level inside band", []Option{WithLevel(zapcore.InfoLevel)}, map[string]string{
+			"info.log": "info", "warn.log": "warn", "error.log": "error dpanic",
+		}},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		logger, closer, err := NewSplit(dir, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		logger.DPanic("dpanic")
+		if err := closer(); err != nil {
+			t.Fatal(err)
+		}
+		for name, want := range tt.files {
+			path := filepath.Join(dir, name)
+			if want == "" {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s: %s exists", tt.name, name)
+				}
+				continue
+			}
+			if got := messagesIn(t, path); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestNewSplitErrors(t *testing.T) {
+	if _, _, err := NewSplit(""); err == nil {
+		t.Error("empty directory accepted")
+	}
+	dir := t.TempDir()
+	if _, _, err := NewSplit(dir, WithLevel(zapcore.ErrorLevel),
+		WithLevelFiles(LevelFile{Name: "info.log", MinLevel: zapcore.DebugLevel, MaxLevel: zapcore.InfoLevel})); err == nil {
+		t.Error("level above every file accepted")
+	}
+	if _, _, err := NewSplit(dir,
+		WithLevelFiles(LevelFile{Name: "bad.log", MinLevel: zapcore.ErrorLevel, MaxLevel: zapcore.InfoLevel})); err == nil {
+		t.Error("MaxLevel below MinLevel accepted")
+	}
+}
+
+func TestNewSplitRotate(t *testing.T) {
+	dir := t.TempDir()
+	logger, closer, err := NewSplit(dir,
+		WithRotate(RotateConfig{MaxSize: 1, MaxBackups: 5}),
+		WithLevelFiles(
+			LevelFile{Name: "rotated.log", MinLevel: zapcore.InfoLevel, MaxLevel: zapcore.FatalLevel},
+			LevelFile{Name: "single.log", MinLevel: zapcore.InfoLevel, MaxLevel: zapcore.FatalLevel, Rotate: &RotateConfig{}},
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := strings.Repeat("x", 1000)
+	for i := 0; i < 1500; i++ { // 约 1.5MB
+		logger.Info(msg)
+	}
+	if err := closer(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按大小轮转的备份名为 <name>-<time>.log
+	count := map[string]int{}
+	for _, e := range entries {
+		count[strings.SplitN(e.Name(), "-", 2)[0]]++
+	}
+	if count["rotated.log"] != 1 || count["rotated"] == 0 {
+		t.Errorf("rotated.log did not rotate with WithRotate: %v", count)
+	}
+	if count["single.log"] != 1 || count["single"] != 0 {
+		t.Errorf("single.log rotated despite its zero Rotate: %v", count)
+	}
+}
+
+func TestNewSplitDirMode(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs", "app")
+	_, closer, err := NewSplit(dir, WithDirMode(0750),
+		WithLevelFiles(LevelFile{Name: "sub/info.log", MinLevel: zapcore.InfoLevel, MaxLevel: zapcore.FatalLevel}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer()
+	for _, d := range []string{filepath.Dir(dir), dir, filepath.Join(dir, "sub")} {
+		info, err := os.Stat(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0750 {
+			t.Errorf("%s created with %v, want 0750", d, perm)
+		}
+	}
+}
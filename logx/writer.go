@@ -0,0 +1,89 @@
+package logx
+
+import (
+	"bytes"
+	"log"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxLineSize 单行的最大字节数，超出时提前写出为一条日志
+const maxLineSize = 64 * 1024
+
+// LineWriter 是一个 io.Writer，将写入的内容按行拆分，每个非空行记录为一条日志
+// 适合接管第三方库输出到 io.Writer 的日志，调用者信息没有意义，因此不记录
+// 未以换行结尾的内容会保留到下一次写入，调用 Sync 或 Close 时写出
+type LineWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewLineWriter 创建以 level 级别写入 logger 的 LineWriter
+func NewLineWriter(logger *zap.Logger, level zapcore.Level) *LineWriter {
+	return &LineWriter{
+		logger: logger.WithOptions(zap.WithCaller(false)),
+		level:  level,
+	}
+}
+
+// Write 实现 io.Writer，总是返回 len(p)
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			if len(w.buf) >= maxLineSize {
+				w.flush()
+			}
+			break
+		}
+		w.buf = append(w.buf, p[:i]...)
+		w.flush()
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush 将缓冲的内容写出为一条日志，调用方需持有锁
+func (w *LineWriter) flush() {
+	line := bytes.TrimRight(w.buf, "\r")
+	if len(bytes.TrimSpace(line)) > 0 {
+		if ce := w.logger.Check(w.level, string(line)); ce != nil {
+			ce.Write()
+		}
+	}
+	w.buf = w.buf[:0]
+}
+
+// Sync 写出未以换行结尾的内容并同步 logger
+func (w *LineWriter) Sync() error {
+	w.mu.Lock()
+	w.flush()
+	w.mu.Unlock()
+	return w.logger.Sync()
+}
+
+// Close 同 Sync，不会关闭 logger
+func (w *LineWriter) Close() error {
+	return w.Sync()
+}
+
+// NewStdLog 返回以 level 级别写入 logger 的 *log.Logger，
+// 可用于 http.Server.ErrorLog 等只接受标准库 logger 的地方，调用者为调用 log 方法的位置
+func NewStdLog(logger *zap.Logger, level zapcore.Level) (*log.Logger, error) {
+	return zap.NewStdLogAt(logger, level)
+}
+
+// RedirectStdLog 将标准库 log 包的全局输出重定向到 logger 的 level 级别，
+// 返回的函数用于恢复原来的输出
+func RedirectStdLog(logger *zap.Logger, level zapcore.Level) (func(), error) {
+	return zap.RedirectStdLogAt(logger, level)
+}
@@ -0,0 +1,309 @@
+package sshx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrClientClosed 客户端已关闭
+var ErrClientClosed = errors.New("sshx: 客户端已关闭")
+
+// Client 可复用的 SSH 客户端，多个 goroutine 可以共享同一个 Client
+// 连接断开（包括 keepalive 超时）后，下次操作时自动重连
+type Client struct {
+	addr      string
+	config    *ssh.ClientConfig
+	opts      options
+	agentConn net.Conn
+
+	mu      sync.Mutex
+	conn    *ssh.Client
+	sftp    *sftp.Client
+	dialing *dialCall // 正在进行的连接，并发的调用等待其结果而不是各自连接
+	closed  bool
+}
+
+// dialCall 一次进行中的连接，done 关闭后 conn 与 err 可读
+type dialCall struct {
+	done chan struct{}
+	conn *ssh.Client
+	err  error
+}
+
+// NewClient 连接 addr（如 "192.168.1.100:22"）并以 user 认证，返回可复用的客户端
+//
+// 必须指定一种主机密钥校验方式：WithKnownHosts、WithPinnedHostKey、WithHostKeyCallback，
+// 或在测试环境中显式使用 WithInsecureIgnoreHostKey；都未指定时返回 ErrNoHostKeyCheck
+//
+//	client, err := sshx.NewClient("192.168.1.100:22", "deploy",
+//		sshx.WithPrivateKeyFile("/home/deploy/.ssh/id_ed25519"),
+//		sshx.WithKnownHosts(sshx.KnownHostsConfig{}),
+//		sshx.WithKeepAlive(30*time.Second, 3),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+func NewClient(addr, user string, opts ...Option) (*Client, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	config, agentConn, err := o.clientConfig(user)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		addr:      addr,
+		config:    config,
+		opts:      *o,
+		agentConn: agentConn,
+	}
+	if _, err := c.SSH(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// SSH 返回当前的 SSH 连接，连接已断开时重新连接
+// 连接在锁外建立，同时调用的 goroutine 共享同一次连接的结果，不阻塞 Close
+func (c *Client) SSH() (*ssh.Client, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	}
+	if c.conn != nil {
+		conn := c.conn
+		c.mu.Unlock()
+		return conn, nil
+	}
+	if call := c.dialing; call != nil {
+		c.mu.Unlock()
+		<-call.done
+		return call.conn, call.err
+	}
+	call := &dialCall{done: make(chan struct{})}
+	c.dialing = call
+	c.mu.Unlock()
+
+	conn, err := dial(c.addr, c.config, &c.opts)
+
+	c.mu.Lock()
+	c.dialing = nil
+	if err == nil && c.closed {
+		conn.Close()
+		conn, err = nil, ErrClientClosed
+	}
+	if err == nil {
+		c.conn = conn
+		go c.watch(conn)
+		if c.opts.keepAliveInterval > 0 {
+			go c.keepAlive(conn)
+		}
+	}
+	c.mu.Unlock()
+
+	call.conn, call.err = conn, err
+	close(call.done)
+	return conn, err
+}
+
+// watch 在连接断开后清除缓存的连接，使下次操作重新连接
+func (c *Client) watch(conn *ssh.Client) {
+	conn.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == conn {
+		c.conn = nil
+		c.sftp = nil
+	}
+}
+
+// keepAlive 定期发送 keepalive 请求，连续多次无响应时关闭连接
+func (c *Client) keepAlive(conn *ssh.Client) {
+	ticker := time.NewTicker(c.opts.keepAliveInterval)
+	defer ticker.Stop()
+	closed := make(chan struct{})
+	go func() {
+		conn.Wait()
+		close(closed)
+	}()
+
+	missed := 0
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		select {
+		case err := <-reply:
+			if err != nil {
+				missed++
+			} else {
+				missed = 0
+			}
+		case <-time.After(c.opts.keepAliveInterval):
+			missed++
+		case <-closed:
+			return
+		}
+		if missed >= c.opts.keepAliveMax {
+			conn.Close()
+			return
+		}
+	}
+}
+
+// NewSession 在当前连接上创建会话，调用方负责关闭
+func (c *Client) NewSession() (*ssh.Session, error) {
+	conn, err := c.SSH()
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("创建 SSH 会话失败: %v", err)
+	}
+	return session, nil
+}
+
+// Run 在远程服务器执行命令，返回标准输出与标准错误的合并内容
+// 命令以非零状态退出时同时返回输出与 *ssh.ExitError
+func (c *Client) Run(cmd string) ([]byte, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	// CombinedOutput 串行化两个输出流的写入，它们由不同的 goroutine 复制
+	return session.CombinedOutput(cmd)
+}
+
+// SFTP 返回当前连接上的 SFTP 客户端，随连接复用，不需要单独关闭
+func (c *Client) SFTP() (*sftp.Client, error) {
+	conn, err := c.SSH()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sftp != nil && c.conn == conn {
+		return c.sftp, nil
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, fmt.Errorf("创建 SFTP 客户端失败: %v", err)
+	}
+	if c.conn == conn {
+		c.sftp = client
+	}
+	return client, nil
+}
+
+// Upload 上传本地文件到远程路径（包括文件名）
+func (c *Client) Upload(localPath, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("打开本地文件失败: %v", err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("获取本地文件信息失败: %v", err)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("本地路径是目录，不能上传: %s", localPath)
+	}
+
+	sftpClient, err := c.SFTP()
+	if err != nil {
+		return err
+	}
+	remoteFile, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("创建远程文件失败: %v", err)
+	}
+	if _, err := io.Copy(remoteFile, file); err != nil {
+		remoteFile.Close()
+		return fmt.Errorf("上传文件内容失败: %v", err)
+	}
+	// 关闭时服务器才确认最后写入的数据，其错误表示上传不完整
+	if err := remoteFile.Close(); err != nil {
+		return fmt.Errorf("关闭远程文件失败: %v", err)
+	}
+	return nil
+}
+
+// Download 下载远程文件到本地路径（包括文件名）
+func (c *Client) Download(remotePath, localPath string) error {
+	sftpClient, err := c.SFTP()
+	if err != nil {
+		return err
+	}
+	remoteFile, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("打开远程文件失败: %v", err)
+	}
+	defer remoteFile.Close()
+
+	fileInfo, err := remoteFile.Stat()
+	if err != nil {
+		return fmt.Errorf("获取远程文件信息失败: %v", err)
+	}
+	if fileInfo.IsDir() {
+		return fmt.Errorf("远程路径是目录，无法下载: %s", remotePath)
+	}
+
+	localFile, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("创建本地文件失败: %v", err)
+	}
+	if _, err := io.Copy(localFile, remoteFile); err != nil {
+		localFile.Close()
+		return fmt.Errorf("下载文件内容失败: %v", err)
+	}
+	if err := localFile.Close(); err != nil {
+		return fmt.Errorf("关闭本地文件失败: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭 SFTP 客户端、SSH 连接与 ssh-agent 连接，之后的操作返回 ErrClientClosed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	var err error
+	if c.sftp != nil {
+		c.sftp.Close()
+		c.sftp = nil
+	}
+	if c.conn != nil {
+		err = c.conn.Close()
+		c.conn = nil
+	}
+	if c.agentConn != nil {
+		c.agentConn.Close()
+	}
+	return err
+}
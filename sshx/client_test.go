@@ -0,0 +1,380 @@
+package sshx
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// testServer 进程内的 SSH 服务器，支持 exec 与 sftp 子系统
+type testServer struct {
+	addr     string
+	hostKey  ssh.Signer
+	accepted atomic.Int32 // 接受的 TCP 连接数
+
+	mu    sync.Mutex
+	conns []*ssh.ServerConn
+	stall chan struct{} // 不为 nil 时新连接在握手前等待其关闭
+}
+
+// testUser 测试用户的各种凭据
+type testUser struct {
+	key      ed25519.PrivateKey
+	keyPEM   []byte
+	ca       ssh.Signer
+	certKey  []byte // 证书对应的私钥
+	cert     []byte // authorized_keys 格式的证书
+	password string
+	code     string // keyboard-interactive 的答案
+}
+
+func newTestUser(t *testing.T) *testUser {
+	t.Helper()
+	u := &testUser{password: "pw", code: "123"}
+	_, u.key, _ = ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(u.key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.keyPEM = pem.EncodeToMemory(block)
+
+	_, caKey, _ := ed25519.GenerateKey(rand.Reader)
+	u.ca, _ = ssh.NewSignerFromKey(caKey)
+	_, certKey, _ := ed25519.GenerateKey(rand.Reader)
+	certSigner, _ := ssh.NewSignerFromKey(certKey)
+	cert := &ssh.Certificate{
+		Key:             certSigner.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"deploy"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, u.ca); err != nil {
+		t.Fatal(err)
+	}
+	u.cert = ssh.MarshalAuthorizedKey(cert)
+	block, _ = ssh.MarshalPrivateKey(certKey, "")
+	u.certKey = pem.EncodeToMemory(block)
+	return u
+}
+
+func startTestServer(t *testing.T, u *testUser) *testServer {
+	t.Helper()
+	_, hk, _ := ed25519.GenerateKey(rand.Reader)
+	s := &testServer{}
+	s.hostKey, _ = ssh.NewSignerFromKey(hk)
+	userKey, _ := ssh.NewSignerFromKey(u.key)
+	checker := &ssh.CertChecker{IsUserAuthority: func(auth ssh.PublicKey) bool {
+		return bytes.Equal(auth.Marshal(), u.ca.PublicKey().Marshal())
+	}}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if string(p) == u.password {
+				return nil, nil
+			}
+			return nil, errors.New("密码错误")
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if _, ok := key.(*ssh.Certificate); ok {
+				return checker.Authenticate(meta, key)
+			}
+			if bytes.Equal(key.Marshal(), userKey.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("未知密钥")
+		},
+		KeyboardInteractiveCallback: func(_ ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"code: "}, []bool{false})
+			if err == nil && len(answers) == 1 && answers[0] == u.code {
+				return nil, nil
+			}
+			return nil, errors.New("验证码错误")
+		},
+	}
+	config.AddHostKey(s.hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ln.Close()
+		s.closeConns()
+	})
+	s.addr = ln.Addr().String()
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.accepted.Add(1)
+			go s.serve(nc, config)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(nc net.Conn, config *ssh.ServerConfig) {
+	s.mu.Lock()
+	stall := s.stall
+	s.mu.Unlock()
+	if stall != nil {
+		<-stall
+	}
+	conn, chans, reqs, err := ssh.NewServerConn(nc, config)
+	if err != nil {
+		nc.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+	go func() {
+		for r := range reqs {
+			r.Reply(r.Type == "keepalive@openssh.com", nil)
+		}
+	}()
+	for nch := range chans {
+		ch, creqs, err := nch.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for r := range creqs {
+				switch r.Type {
+				case "exec":
+					r.Reply(true, nil)
+					fmt.Fprintf(ch, "ran %s", r.Payload[4:])
+					ch.SendRequest("exit-status", false, []byte{0, 0, 0, 0})
+					ch.Close()
+				case "subsystem":
+					r.Reply(true, nil)
+					go func() {
+						server, _ := sftp.NewServer(ch)
+						server.Serve()
+						ch.Close()
+					}()
+				default:
+					r.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+// closeConns 断开所有已建立的连接
+func (s *testServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+// startAgent 启动持有 key 的 ssh-agent，返回其 socket 路径
+func startAgent(t *testing.T, key interface{}) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, c)
+		}
+	}()
+	return sock
+}
+
+func TestClientAuthMethods(t *testing.T) {
+	u := newTestUser(t)
+	s := startTestServer(t, u)
+	sock := startAgent(t, u.key)
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"password", []Option{WithPassword("pw")}, false},
+		{"private key", []Option{WithPrivateKey(u.keyPEM)}, false},
+		{"certificate", []Option{WithCertificate(u.cert, u.certKey)}, false},
+		{"agent", []Option{WithAgent(sock)}, false},
+		{"keyboard-interactive", []Option{WithKeyboardInteractive(
+			func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				return []string{"123"}, nil
+			})}, false},
+		{"wrong password", []Option{WithPassword("nope")}, true},
+		{"unknown key falls back to password", []Option{WithPrivateKey(u.certKey), WithPassword("pw")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(s.addr, "deploy", append(tt.opts, WithInsecureIgnoreHostKey())...)
+			if tt.wantErr {
+				if err == nil {
+					c.Close()
+					t.Fatal("NewClient succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			out, err := c.Run("uptime")
+			if err != nil || string(out) != "ran uptime" {
+				t.Errorf("Run = %q, %v", out, err)
+			}
+		})
+	}
+}
+
+func TestClientTransfer(t *testing.T) {
+	u := newTestUser(t)
+	s := startTestServer(t, u)
+	c, err := NewClient(s.addr, "deploy", WithPassword("pw"), WithInsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.txt")
+	if err := os.WriteFile(local, []byte("payload"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(dir, "remote.txt")
+	if err := c.Upload(local, remote); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	back := filepath.Join(dir, "back.txt")
+	if err := c.Download(remote, back); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if b, _ := os.ReadFile(back); string(b) != "payload" {
+		t.Errorf("downloaded %q", b)
+	}
+	if err := c.Upload(dir, remote); err == nil {
+		t.Error("uploading a directory succeeded")
+	}
+	if err := c.Upload(local, filepath.Join(dir, "missing", "x.txt")); err == nil {
+		t.Error("upload into a missing directory succeeded")
+	}
+}
+
+func TestClientReconnects(t *testing.T) {
+	u := newTestUser(t)
+	s := startTestServer(t, u)
+	c, err := NewClient(s.addr, "deploy", WithPassword("pw"), WithInsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := c.SSH()
+	s.closeConns()
+	first.Wait()
+
+	var out []byte
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if out, err = c.Run("again"); err == nil {
+			break
+		}
+	}
+	if err != nil || string(out) != "ran again" {
+		t.Fatalf("Run after disconnect = %q, %v", out, err)
+	}
+	if second, _ := c.SSH(); second == first {
+		t.Error("still using the closed connection")
+	}
+	c.Close()
+	if _, err := c.Run("x"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Run after Close = %v", err)
+	}
+}
+
+// 连接期间不持有锁：并发的 SSH 调用共享一次连接，Close 不等待连接完成
+func TestClientDialOutsideLock(t *testing.T) {
+	u := newTestUser(t)
+	s := startTestServer(t, u)
+	c, err := NewClient(s.addr, "deploy", WithPassword("pw"), WithInsecureIgnoreHostKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := c.SSH()
+
+	stall := make(chan struct{})
+	s.mu.Lock()
+	s.stall = stall
+	s.mu.Unlock()
+	s.closeConns()
+	first.Wait()
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+		c.mu.Lock()
+		gone := c.conn == nil
+		c.mu.Unlock()
+		if gone {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("disconnect not noticed")
+		}
+	}
+
+	accepted := s.accepted.Load()
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := c.SSH()
+			errs <- err
+		}()
+	}
+	for deadline := time.Now().Add(2 * time.Second); s.accepted.Load() == accepted; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no reconnect attempt")
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind the dial")
+	}
+	close(stall)
+	for i := 0; i < 5; i++ {
+		if err := <-errs; !errors.Is(err, ErrClientClosed) {
+			t.Errorf("SSH = %v, want ErrClientClosed", err)
+		}
+	}
+	if n := s.accepted.Load() - accepted; n != 1 {
+		t.Errorf("%d concurrent dials, want 1", n)
+	}
+}
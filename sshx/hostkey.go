@@ -0,0 +1,244 @@
+package sshx
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KnownHostsConfig 基于 known_hosts 文件的主机密钥校验配置
+type KnownHostsConfig struct {
+	Files           []string // known_hosts 文件，默认 ~/.ssh/known_hosts；首次信任的主机写入第一个文件
+	TrustOnFirstUse bool     // 首次连接的主机自动信任并写入文件；为 false 时为严格模式，未知主机直接报错
+	HashHosts       bool     // 写入时对主机名做哈希，同 OpenSSH 的 HashKnownHosts yes
+}
+
+// KnownHosts 基于 known_hosts 文件校验主机密钥，支持哈希主机名以及 @cert-authority、@revoked 标记
+// 每次校验时重新读取文件，其他进程或客户端写入的记录立即生效
+// 已知主机的密钥与记录不符时总是返回 *HostKeyError
+type KnownHosts struct {
+	cfg KnownHostsConfig
+	mu  sync.Mutex // 串行化读取与追加
+}
+
+// NewKnownHosts 创建 known_hosts 校验，已存在的文件必须能够解析
+func NewKnownHosts(cfg KnownHostsConfig) (*KnownHosts, error) {
+	if len(cfg.Files) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("获取用户主目录失败: %v", err)
+		}
+		cfg.Files = []string{filepath.Join(home, ".ssh", "known_hosts")}
+	}
+	cfg.Files = append([]string(nil), cfg.Files...)
+	k := &KnownHosts{cfg: cfg}
+	if _, err := k.load(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// load 读取已存在的 known_hosts 文件，都不存在时返回 nil
+func (k *KnownHosts) load() (ssh.HostKeyCallback, error) {
+	var files []string
+	for _, f := range k.cfg.Files {
+		if _, err := os.Stat(f); err == nil {
+			files = append(files, f)
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("读取 known_hosts 失败: %v", err)
+		}
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	cb, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("解析 known_hosts 失败: %v", err)
+	}
+	return cb, nil
+}
+
+// HostKeyCallback 返回用于 ssh.ClientConfig 的校验函数
+func (k *KnownHosts) HostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		cb, err := k.load()
+		if err != nil {
+			return err
+		}
+		err = &knownhosts.KeyError{}
+		if cb != nil {
+			err = cb(hostname, remote, key)
+		}
+
+		var keyErr *knownhosts.KeyError
+		var revoked *knownhosts.RevokedError
+		switch {
+		case errors.As(err, &keyErr) && len(keyErr.Want) == 0 && k.cfg.TrustOnFirstUse:
+			return k.add(hostname, remote, key)
+		case errors.As(err, &keyErr):
+			return &HostKeyError{Host: hostname, Key: key, Known: keyErr.Want, Files: k.cfg.Files}
+		case errors.As(err, &revoked):
+			return &HostKeyError{Host: hostname, Key: key, Revoked: &revoked.Revoked}
+		}
+		return err
+	}
+}
+
+// Add 将主机密钥追加到第一个 known_hosts 文件，remote 不为 nil 且与 addr 不同时一并记录其 IP
+func (k *KnownHosts) Add(addr string, remote net.Addr, key ssh.PublicKey) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.add(addr, remote, key)
+}
+
+func (k *KnownHosts) add(addr string, remote net.Addr, key ssh.PublicKey) error {
+	hosts := []string{knownhosts.Normalize(addr)}
+	if remote != nil {
+		if ip := knownhosts.Normalize(remote.String()); ip != hosts[0] {
+			hosts = append(hosts, ip)
+		}
+	}
+	if k.cfg.HashHosts {
+		for i, h := range hosts {
+			hosts[i] = knownhosts.HashHostname(h)
+		}
+	}
+
+	file := k.cfg.Files[0]
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return fmt.Errorf("创建 known_hosts 目录失败: %v", err)
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开 known_hosts 失败: %v", err)
+	}
+	defer f.Close()
+	// 文件不以换行结尾时先补一个换行，避免与上一条记录连在一起
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			f.WriteString("\n")
+		}
+	}
+	if _, err := f.WriteString(knownhosts.Line(hosts, key) + "\n"); err != nil {
+		return fmt.Errorf("写入 known_hosts 失败: %v", err)
+	}
+	return nil
+}
+
+// probeKey 用于查询主机已记录的密钥，不会与任何记录匹配
+var probeKey = sync.OnceValue(func() ssh.PublicKey {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	return key
+})
+
+// HostKeyAlgorithms 返回 addr 已记录密钥的算法，用于 ssh.ClientConfig.HostKeyAlgorithms，
+// 使服务器提供与记录相同类型的密钥；主机未知时返回 nil
+func (k *KnownHosts) HostKeyAlgorithms(addr string) []string {
+	k.mu.Lock()
+	cb, err := k.load()
+	k.mu.Unlock()
+	if err != nil || cb == nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(cb(addr, &net.TCPAddr{}, probeKey()), &keyErr) {
+		return nil
+	}
+	var algos []string
+	seen := make(map[string]bool)
+	for _, known := range keyErr.Want {
+		for _, algo := range keyAlgorithms(known.Key.Type()) {
+			if !seen[algo] {
+				seen[algo] = true
+				algos = append(algos, algo)
+			}
+		}
+	}
+	return algos
+}
+
+// keyAlgorithms 返回密钥类型可用的签名算法，RSA 密钥可以使用 SHA-2 签名
+func keyAlgorithms(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// PinnedHostKey 返回只接受指定 SHA256 指纹的主机密钥校验，指纹格式与 ssh-keygen -lf 的输出一致，
+// 如 "SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s"；服务器提供证书时也可以固定证书中的密钥
+func PinnedHostKey(fingerprints ...string) ssh.HostKeyCallback {
+	pinned := make([]string, 0, len(fingerprints))
+	for _, fp := range fingerprints {
+		fp = strings.TrimRight(strings.TrimSpace(fp), "=")
+		if !strings.HasPrefix(fp, "SHA256:") {
+			fp = "SHA256:" + fp
+		}
+		pinned = append(pinned, fp)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		candidates := []string{ssh.FingerprintSHA256(key)}
+		if cert, ok := key.(*ssh.Certificate); ok {
+			candidates = append(candidates, ssh.FingerprintSHA256(cert.Key))
+		}
+		for _, fp := range pinned {
+			for _, c := range candidates {
+				if fp == c {
+					return nil
+				}
+			}
+		}
+		return &HostKeyError{Host: hostname, Key: key, Pinned: pinned}
+	}
+}
+
+// HostKeyError 主机密钥校验失败
+type HostKeyError struct {
+	Host    string                // 连接地址
+	Key     ssh.PublicKey         // 服务器提供的密钥
+	Known   []knownhosts.KnownKey // known_hosts 中记录的密钥，为空且 Pinned、Revoked 均为空时表示主机未知
+	Files   []string              // 查找过的 known_hosts 文件
+	Pinned  []string              // 固定的指纹
+	Revoked *knownhosts.KnownKey  // 吊销该密钥的记录
+}
+
+// Mismatch 判断是否为密钥已被吊销，或与记录、固定指纹不符（可能存在中间人攻击）
+// 为 false 时表示主机未知
+func (e *HostKeyError) Mismatch() bool {
+	return e.Revoked != nil || len(e.Known) > 0 || len(e.Pinned) > 0
+}
+
+func (e *HostKeyError) Error() string {
+	got := e.Key.Type() + " " + ssh.FingerprintSHA256(e.Key)
+	switch {
+	case e.Revoked != nil:
+		return fmt.Sprintf("主机 %s 的密钥 %s 已被吊销（%s:%d）", e.Host, got, e.Revoked.Filename, e.Revoked.Line)
+	case len(e.Known) > 0:
+		var want []string
+		for _, k := range e.Known {
+			want = append(want, fmt.Sprintf("%s %s（%s:%d）", k.Key.Type(), ssh.FingerprintSHA256(k.Key), k.Filename, k.Line))
+		}
+		return fmt.Sprintf("主机 %s 的密钥不匹配，可能存在中间人攻击: 服务器提供 %s，已记录 %s；"+
+			"如确认主机密钥已更换，请删除旧记录（ssh-keygen -R %s）后重试",
+			e.Host, got, strings.Join(want, "、"), knownhosts.Normalize(e.Host))
+	case len(e.Pinned) > 0:
+		return fmt.Sprintf("主机 %s 的密钥不匹配，可能存在中间人攻击: 服务器提供 %s，期望 %s",
+			e.Host, got, strings.Join(e.Pinned, "、"))
+	default:
+		return fmt.Sprintf("主机 %s 不在 known_hosts（%s）中，严格模式下拒绝连接: 服务器提供 %s",
+			e.Host, strings.Join(e.Files, "、"), got)
+	}
+}
@@ -0,0 +1,165 @@
+package sshx
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// writeKnownHosts 写入 known_hosts 文件并返回其路径
+func writeKnownHosts(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func otherHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// connect 以密码认证连接 s，成功时关闭连接，返回错误
+func connect(s *testServer, opts ...Option) error {
+	c, err := NewClient(s.addr, "deploy", append([]Option{WithPassword("pw")}, opts...)...)
+	if err == nil {
+		c.Close()
+	}
+	return err
+}
+
+func hostKeyError(t *testing.T, err error) *HostKeyError {
+	t.Helper()
+	var hostKeyErr *HostKeyError
+	if !errors.As(err, &hostKeyErr) {
+		t.Fatalf("err = %v, want *HostKeyError", err)
+	}
+	return hostKeyErr
+}
+
+func TestNewClientRequiresHostKeyCheck(t *testing.T) {
+	s := startTestServer(t, newTestUser(t))
+	if err := connect(s); !errors.Is(err, ErrNoHostKeyCheck) {
+		t.Errorf("NewClient without a host key option = %v", err)
+	}
+	if err := connect(s, WithInsecureIgnoreHostKey()); err != nil {
+		t.Errorf("WithInsecureIgnoreHostKey: %v", err)
+	}
+	// 旧版函数保持不校验的行为
+	conn, err := CreateSSHConn(s.addr, "deploy", "pw")
+	if err != nil {
+		t.Fatalf("CreateSSHConn: %v", err)
+	}
+	conn.Close()
+}
+
+func TestKnownHostsStrict(t *testing.T) {
+	s := startTestServer(t, newTestUser(t))
+	host := knownhosts.Normalize(s.addr)
+
+	known := writeKnownHosts(t, knownhosts.Line([]string{host}, s.hostKey.PublicKey()))
+	if err := connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{known}})); err != nil {
+		t.Errorf("known host rejected: %v", err)
+	}
+
+	unknown := writeKnownHosts(t, knownhosts.Line([]string{"[example.com]:22"}, s.hostKey.PublicKey()))
+	e := hostKeyError(t, connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{unknown}})))
+	if e.Mismatch() || len(e.Known) != 0 {
+		t.Errorf("unknown host reported as mismatch: %v", e)
+	}
+
+	changed := writeKnownHosts(t, knownhosts.Line([]string{host}, otherHostKey(t)))
+	e = hostKeyError(t, connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{changed}})))
+	if !e.Mismatch() || len(e.Known) != 1 || !strings.Contains(e.Error(), "ssh-keygen -R") {
+		t.Errorf("changed key not reported as mismatch: %v", e)
+	}
+
+	revoked := writeKnownHosts(t,
+		knownhosts.Line([]string{host}, s.hostKey.PublicKey()),
+		"@revoked * "+strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.hostKey.PublicKey()))))
+	e = hostKeyError(t, connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{revoked}})))
+	if !e.Mismatch() || e.Revoked == nil {
+		t.Errorf("revoked key not reported as mismatch: %v", e)
+	}
+}
+
+func TestKnownHostsHashed(t *testing.T) {
+	s := startTestServer(t, newTestUser(t))
+	host := knownhosts.Normalize(s.addr)
+	known := writeKnownHosts(t, knownhosts.Line([]string{knownhosts.HashHostname(host)}, s.hostKey.PublicKey()))
+	if err := connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{known}})); err != nil {
+		t.Errorf("hashed entry not matched: %v", err)
+	}
+}
+
+func TestKnownHostsTrustOnFirstUse(t *testing.T) {
+	s := startTestServer(t, newTestUser(t))
+	for _, hash := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "ssh", "known_hosts") // 目录不存在时自动创建
+		tofu := KnownHostsConfig{Files: []string{path}, TrustOnFirstUse: true, HashHosts: hash}
+		if err := connect(s, WithKnownHosts(tofu)); err != nil {
+			t.Fatalf("first use: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := string(data)
+		if hashed := strings.HasPrefix(line, "|1|"); hashed != hash || strings.Count(line, "\n") != 1 {
+			t.Errorf("HashHosts=%v wrote %q", hash, line)
+		}
+		if !hash && !strings.HasPrefix(line, knownhosts.Normalize(s.addr)+" ") {
+			t.Errorf("recorded %q", line)
+		}
+
+		// 记录后严格模式也能连接，且不再追加
+		if err := connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{path}})); err != nil {
+			t.Errorf("strict after first use: %v", err)
+		}
+		if err := connect(s, WithKnownHosts(tofu)); err != nil {
+			t.Errorf("second use: %v", err)
+		}
+		if again, _ := os.ReadFile(path); string(again) != line {
+			t.Errorf("known host appended again: %q", again)
+		}
+	}
+
+	// 已记录的主机密钥变化时，首次信任模式同样拒绝
+	path := writeKnownHosts(t, knownhosts.Line([]string{knownhosts.Normalize(s.addr)}, otherHostKey(t)))
+	e := hostKeyError(t, connect(s, WithKnownHosts(KnownHostsConfig{Files: []string{path}, TrustOnFirstUse: true})))
+	if !e.Mismatch() {
+		t.Errorf("changed key accepted on first use: %v", e)
+	}
+}
+
+func TestPinnedHostKey(t *testing.T) {
+	s := startTestServer(t, newTestUser(t))
+	fp := ssh.FingerprintSHA256(s.hostKey.PublicKey())
+
+	for _, pin := range []string{fp, strings.TrimPrefix(fp, "SHA256:"), fp + "="} {
+		if err := connect(s, WithPinnedHostKey(ssh.FingerprintSHA256(otherHostKey(t)), pin)); err != nil {
+			t.Errorf("pin %q rejected: %v", pin, err)
+		}
+	}
+	e := hostKeyError(t, connect(s, WithPinnedHostKey(ssh.FingerprintSHA256(otherHostKey(t)))))
+	if !e.Mismatch() || !strings.Contains(e.Error(), fp) {
+		t.Errorf("wrong pin: %v", e)
+	}
+	if err := connect(s, WithPinnedHostKey()); err == nil {
+		t.Error("WithPinnedHostKey without fingerprints succeeded")
+	}
+}
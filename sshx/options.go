@@ -0,0 +1,325 @@
+package sshx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// ErrNoHostKeyCheck NewClient 未指定主机密钥校验方式
+var ErrNoHostKeyCheck = errors.New("sshx: 未指定主机密钥校验方式，请使用 WithKnownHosts、WithPinnedHostKey、WithHostKeyCallback，" +
+	"或在测试环境中显式使用 WithInsecureIgnoreHostKey")
+
+// Option 配置 NewClient 创建的客户端
+type Option func(*options) error
+
+type options struct {
+	signers           []ssh.Signer // 私钥与证书，按添加顺序尝试
+	password          *string
+	interactive       ssh.KeyboardInteractiveChallenge
+	agentSocket       *string // 为空字符串时使用 SSH_AUTH_SOCK
+	hostKey           ssh.HostKeyCallback
+	hostAlgos         func(addr string) []string // 按 known_hosts 记录限定服务器密钥算法
+	hostKeyAlgorithms []string                   // 显式指定的服务器密钥算法，优先于 hostAlgos
+
+	dialTimeout       time.Duration
+	handshakeTimeout  time.Duration
+	keepAliveInterval time.Duration
+	keepAliveMax      int
+}
+
+// WithPassword 使用密码认证
+func WithPassword(password string) Option {
+	return func(o *options) error {
+		o.password = &password
+		return nil
+	}
+}
+
+// WithPrivateKey 使用私钥认证，支持 PEM（PKCS#1、PKCS#8、EC）与 OpenSSH 格式
+// 私钥已加密时传入 passphrase，未加密时不传
+func WithPrivateKey(key []byte, passphrase ...string) Option {
+	return func(o *options) error {
+		signer, err := parsePrivateKey(key, passphrase)
+		if err != nil {
+			return err
+		}
+		o.signers = append(o.signers, signer)
+		return nil
+	}
+}
+
+// WithPrivateKeyFile 从文件读取私钥，见 WithPrivateKey
+func WithPrivateKeyFile(path string, passphrase ...string) Option {
+	return func(o *options) error {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取私钥文件失败: %v", err)
+		}
+		return WithPrivateKey(key, passphrase...)(o)
+	}
+}
+
+// WithCertificate 使用 OpenSSH 证书认证，cert 为 authorized_keys 格式的证书（如 id_ed25519-cert.pub 的内容），
+// key 为对应的私钥，见 WithPrivateKey
+func WithCertificate(cert, key []byte, passphrase ...string) Option {
+	return func(o *options) error {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey(cert)
+		if err != nil {
+			return fmt.Errorf("解析证书失败: %v", err)
+		}
+		c, ok := pub.(*ssh.Certificate)
+		if !ok {
+			return fmt.Errorf("解析证书失败: 不是 OpenSSH 证书")
+		}
+		signer, err := parsePrivateKey(key, passphrase)
+		if err != nil {
+			return err
+		}
+		certSigner, err := ssh.NewCertSigner(c, signer)
+		if err != nil {
+			return fmt.Errorf("证书与私钥不匹配: %v", err)
+		}
+		o.signers = append(o.signers, certSigner)
+		return nil
+	}
+}
+
+// WithCertificateFile 从文件读取证书与私钥，见 WithCertificate
+func WithCertificateFile(certPath, keyPath string, passphrase ...string) Option {
+	return func(o *options) error {
+		cert, err := os.ReadFile(certPath)
+		if err != nil {
+			return fmt.Errorf("读取证书文件失败: %v", err)
+		}
+		key, err := os.ReadFile(keyPath)
+		if err != nil {
+			return fmt.Errorf("读取私钥文件失败: %v", err)
+		}
+		return WithCertificate(cert, key, passphrase...)(o)
+	}
+}
+
+// WithAgent 使用 ssh-agent 中的密钥认证，socket 为空时使用环境变量 SSH_AUTH_SOCK
+func WithAgent(socket string) Option {
+	return func(o *options) error {
+		o.agentSocket = &socket
+		return nil
+	}
+}
+
+// WithKeyboardInteractive 使用 keyboard-interactive 认证，challenge 回答服务器的提问
+func WithKeyboardInteractive(challenge ssh.KeyboardInteractiveChallenge) Option {
+	return func(o *options) error {
+		o.interactive = challenge
+		return nil
+	}
+}
+
+// WithHostKeyCallback 设置自定义的主机密钥校验
+func WithHostKeyCallback(callback ssh.HostKeyCallback) Option {
+	return func(o *options) error {
+		o.hostKey, o.hostAlgos = callback, nil
+		return nil
+	}
+}
+
+// WithKnownHosts 按 known_hosts 文件校验主机密钥，见 KnownHostsConfig
+//
+//	sshx.WithKnownHosts(sshx.KnownHostsConfig{})                      // 严格模式，使用 ~/.ssh/known_hosts
+//	sshx.WithKnownHosts(sshx.KnownHostsConfig{TrustOnFirstUse: true}) // 首次连接时信任并记录
+func WithKnownHosts(cfg KnownHostsConfig) Option {
+	return func(o *options) error {
+		k, err := NewKnownHosts(cfg)
+		if err != nil {
+			return err
+		}
+		o.hostKey, o.hostAlgos = k.HostKeyCallback(), k.HostKeyAlgorithms
+		return nil
+	}
+}
+
+// WithPinnedHostKey 只接受指定 SHA256 指纹的主机密钥，见 PinnedHostKey
+// 服务器有多个主机密钥时提供哪一个由协商的算法决定，可以固定全部密钥的指纹，或使用 WithHostKeyAlgorithms 指定算法
+func WithPinnedHostKey(fingerprints ...string) Option {
+	return func(o *options) error {
+		if len(fingerprints) == 0 {
+			return fmt.Errorf("未指定主机密钥指纹")
+		}
+		o.hostKey, o.hostAlgos = PinnedHostKey(fingerprints...), nil
+		return nil
+	}
+}
+
+// WithHostKeyAlgorithms 按优先顺序指定接受的服务器密钥算法，如 ssh.KeyAlgoED25519
+// 服务器有多个主机密钥时，配合 WithPinnedHostKey 使服务器提供被固定的那个密钥
+func WithHostKeyAlgorithms(algorithms ...string) Option {
+	return func(o *options) error {
+		o.hostKeyAlgorithms = algorithms
+		return nil
+	}
+}
+
+// WithInsecureIgnoreHostKey 不校验主机密钥，任何服务器（包括中间人）都会被接受，仅用于测试环境
+func WithInsecureIgnoreHostKey() Option {
+	return WithHostKeyCallback(ssh.InsecureIgnoreHostKey())
+}
+
+// WithTimeout 设置建立 TCP 连接的超时时间，默认 10 秒
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.dialTimeout = d
+		return nil
+	}
+}
+
+// WithHandshakeTimeout 设置 SSH 握手与认证的超时时间，默认 30 秒
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.handshakeTimeout = d
+		return nil
+	}
+}
+
+// WithKeepAlive 每隔 interval 发送一次 keepalive 请求，连续 maxMissed 次无响应时断开连接，
+// 下次操作时自动重连；maxMissed 小于 1 时为 3
+func WithKeepAlive(interval time.Duration, maxMissed int) Option {
+	return func(o *options) error {
+		if maxMissed < 1 {
+			maxMissed = 3
+		}
+		o.keepAliveInterval, o.keepAliveMax = interval, maxMissed
+		return nil
+	}
+}
+
+// clientConfig 根据选项创建 ssh.ClientConfig，同时返回需要在不再认证时断开的 ssh-agent 连接
+func (o *options) clientConfig(user string) (*ssh.ClientConfig, net.Conn, error) {
+	if o.hostKey == nil {
+		return nil, nil, ErrNoHostKeyCheck
+	}
+	methods, agentConn, err := o.authMethods()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ssh.ClientConfig{
+		User:              user,
+		Auth:              methods,
+		HostKeyCallback:   o.hostKey,
+		HostKeyAlgorithms: o.hostKeyAlgorithms,
+		Timeout:           o.dialTimeout,
+	}, agentConn, nil
+}
+
+// newOptions 应用选项并填充默认的超时时间
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		dialTimeout:      10 * time.Second,
+		handshakeTimeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// dial 建立 TCP 连接并在握手超时时间内完成 SSH 握手与认证
+func dial(addr string, config *ssh.ClientConfig, o *options) (*ssh.Client, error) {
+	if o.hostAlgos != nil && len(config.HostKeyAlgorithms) == 0 {
+		cfg := *config
+		cfg.HostKeyAlgorithms = o.hostAlgos(addr)
+		config = &cfg
+	}
+	conn, err := net.DialTimeout("tcp", addr, o.dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到服务器 %s: %v", addr, err)
+	}
+	if o.handshakeTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(o.handshakeTimeout))
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		var hostKeyErr *HostKeyError
+		if errors.As(err, &hostKeyErr) {
+			return nil, hostKeyErr
+		}
+		return nil, fmt.Errorf("SSH 握手失败 %s: %v", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+// parsePrivateKey 解析私钥，passphrase 最多取第一个
+func parsePrivateKey(key []byte, passphrase []string) (ssh.Signer, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+	if len(passphrase) > 0 && passphrase[0] != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase[0]))
+	} else {
+		signer, err = ssh.ParsePrivateKey(key)
+	}
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		return nil, fmt.Errorf("私钥已加密，需要提供密码")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	return signer, nil
+}
+
+// authMethods 按公钥（私钥、证书与 ssh-agent）、密码、keyboard-interactive 的顺序返回认证方式，
+// 同时返回需要在关闭客户端时断开的 ssh-agent 连接
+func (o *options) authMethods() ([]ssh.AuthMethod, net.Conn, error) {
+	var (
+		methods   []ssh.AuthMethod
+		agentConn net.Conn
+	)
+	if o.agentSocket != nil {
+		socket := *o.agentSocket
+		if socket == "" {
+			socket = os.Getenv("SSH_AUTH_SOCK")
+		}
+		if socket == "" {
+			return nil, nil, fmt.Errorf("未设置 SSH_AUTH_SOCK，无法连接 ssh-agent")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("连接 ssh-agent 失败: %v", err)
+		}
+		agentConn = conn
+		keyring := agent.NewClient(conn)
+		// 每次认证时重新读取 ssh-agent 中的密钥，重连时可以使用新添加的密钥
+		methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			agentSigners, err := keyring.Signers()
+			if err != nil {
+				return nil, fmt.Errorf("读取 ssh-agent 密钥失败: %v", err)
+			}
+			return append(o.signers[:len(o.signers):len(o.signers)], agentSigners...), nil
+		}))
+	} else if len(o.signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(o.signers...))
+	}
+	if o.password != nil {
+		methods = append(methods, ssh.Password(*o.password))
+	}
+	if o.interactive != nil {
+		methods = append(methods, ssh.KeyboardInteractive(o.interactive))
+	}
+	if len(methods) == 0 {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, fmt.Errorf("未配置任何认证方式")
+	}
+	return methods, agentConn, nil
+}
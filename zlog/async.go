@@ -0,0 +1,283 @@
+package zlog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Policies applied by AsyncWriter when its buffer is full.
+const (
+	AsyncBlock      = "block"       // wait for free space (no loss, may add latency)
+	AsyncDropNewest = "drop_newest" // discard the entry being written
+	AsyncDropOldest = "drop_oldest" // discard the oldest buffered entry
+)
+
+// AsyncConfig enables buffered, asynchronous writes to the log outputs.
+type AsyncConfig struct {
+	BufferSize    int           `yaml:"buffer_size"`    // queued entries, default 8192
+	FlushInterval time.Duration `yaml:"flush_interval"` // default 1s
+	Policy        string        `yaml:"policy"`         // block、drop_newest、drop_oldest
+}
+
+// AsyncStats reports counters of asynchronous writers.
+type AsyncStats struct {
+	Written uint64 // entries handed to the underlying output
+	Dropped uint64 // entries discarded because the buffer was full
+	Pending int    // entries currently queued
+}
+
+// AsyncWriter is a zapcore.WriteSyncer that queues entries and writes them
+// from a background goroutine. Sync drains the queue and flushes the output.
+type AsyncWriter struct {
+	out      zapcore.WriteSyncer
+	buf      *bufio.Writer
+	queue    chan []byte
+	policy   string
+	interval time.Duration
+
+	flushReq  chan chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+	mu        sync.RWMutex // read-held while queueing, so Close cannot strand an entry in the queue
+	closed    atomic.Bool  // set under mu
+	outMu     sync.Mutex   // serializes direct writes after Close
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// NewAsyncWriter starts an AsyncWriter in front of out.
+func NewAsyncWriter(out zapcore.WriteSyncer, cfg AsyncConfig) *AsyncWriter {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 8192
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	switch cfg.Policy {
+	case AsyncBlock, AsyncDropNewest, AsyncDropOldest:
+	default:
+		cfg.Policy = AsyncBlock
+	}
+	w := &AsyncWriter{
+		out:      out,
+		buf:      bufio.NewWriterSize(out, 256*1024),
+		queue:    make(chan []byte, cfg.BufferSize),
+		policy:   cfg.Policy,
+		interval: cfg.FlushInterval,
+		flushReq: make(chan chan struct{}),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. zap reuses p after Write returns.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	if w.closed.Load() {
+		w.mu.RUnlock()
+		return w.writeDirect(p)
+	}
+	defer w.mu.RUnlock()
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	switch w.policy {
+	case AsyncDropNewest:
+		select {
+		case w.queue <- entry:
+		default:
+			w.dropped.Add(1)
+		}
+	case AsyncDropOldest:
+		for {
+			select {
+			case w.queue <- entry:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-w.queue:
+				w.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		// The background goroutine keeps draining until Close has taken mu,
+		// which waits for this write.
+		w.queue <- entry
+	}
+	return len(p), nil
+}
+
+// writeDirect writes p to the output once the background goroutine has
+// drained the queue, keeping entries in order.
+func (w *AsyncWriter) writeDirect(p []byte) (int, error) {
+	<-w.stopped
+	w.outMu.Lock()
+	defer w.outMu.Unlock()
+	return w.out.Write(p)
+}
+
+// Sync blocks until every queued entry has been written, then syncs the output.
+func (w *AsyncWriter) Sync() error {
+	if !w.closed.Load() {
+		ack := make(chan struct{})
+		select {
+		case w.flushReq <- ack:
+			<-ack
+		case <-w.stopped:
+		}
+	}
+	return w.out.Sync()
+}
+
+// Close drains the queue and stops the background goroutine.
+// Later writes go directly to the underlying output.
+func (w *AsyncWriter) Close() error {
+	w.closeOnce.Do(func() {
+		// Writers that saw closed unset have queued their entries once the
+		// lock is ours; the background goroutine drains them before stopping.
+		w.mu.Lock()
+		w.closed.Store(true)
+		w.mu.Unlock()
+		close(w.done)
+		<-w.stopped
+	})
+	return w.out.Sync()
+}
+
+// Stats returns the writer's counters.
+func (w *AsyncWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Pending: len(w.queue),
+	}
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case p := <-w.queue:
+			w.write(p)
+		case <-ticker.C:
+			w.flush()
+		case ack := <-w.flushReq:
+			w.drain()
+			w.flush()
+			close(ack)
+		case <-w.done:
+			w.drain()
+			w.flush()
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) drain() {
+	for {
+		select {
+		case p := <-w.queue:
+			w.write(p)
+		default:
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) write(p []byte) {
+	if _, err := w.buf.Write(p); err != nil {
+		fmt.Fprintf(os.Stderr, "[zlog] async write error: %v\n", err)
+		return
+	}
+	w.written.Add(1)
+}
+
+func (w *AsyncWriter) flush() {
+	if err := w.buf.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "[zlog] async flush error: %v\n", err)
+	}
+}
+
+// AsyncStats returns the counters summed over l's asynchronous outputs.
+func (l *Logger) AsyncStats() AsyncStats {
+	var total AsyncStats
+	for _, w := range l.parts.writers {
+		s := w.Stats()
+		total.Written += s.Written
+		total.Dropped += s.Dropped
+		total.Pending += s.Pending
+	}
+	return total
+}
+
+// GetAsyncStats returns the asynchronous output counters of the default instance.
+func GetAsyncStats() AsyncStats {
+	return L().AsyncStats()
+}
+
+// Shutdown flushes the default instance and closes its asynchronous writers.
+// It returns ctx.Err() if draining does not finish before ctx is done.
+func Shutdown(ctx context.Context) error {
+	l := L()
+	done := make(chan error, 1)
+	go func() { done <- l.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownOnSignal drains the logger when one of signals (default SIGTERM and
+// SIGINT) arrives, then re-raises the signal so the process terminates as usual.
+// The returned function cancels the handler.
+func ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ch := make(chan os.Signal, 1)
+	quit := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			if err := Shutdown(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "[zlog] shutdown error: %v\n", err)
+			}
+			cancel()
+			signal.Stop(ch)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(sig)
+			}
+		case <-quit:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(quit) }) }
+}
@@ -0,0 +1,61 @@
+package zlog
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) Sync() error { return nil }
+
+func (b *lockedBuffer) lines() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Count(b.buf.Bytes(), []byte("\n"))
+}
+
+// TestAsyncWriterCloseKeepsEntries writes concurrently with Close and checks
+// that no entry is stranded in the queue.
+func TestAsyncWriterCloseKeepsEntries(t *testing.T) {
+	for _, policy := range []string{AsyncBlock, AsyncDropNewest, AsyncDropOldest} {
+		t.Run(policy, func(t *testing.T) {
+			for round := 0; round < 50; round++ {
+				out := &lockedBuffer{}
+				w := NewAsyncWriter(out, AsyncConfig{BufferSize: 4, Policy: policy})
+
+				const writers, perWriter = 8, 50
+				var wg sync.WaitGroup
+				for i := 0; i < writers; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for j := 0; j < perWriter; j++ {
+							_, _ = w.Write([]byte("entry\n"))
+						}
+					}()
+				}
+				_ = w.Close()
+				wg.Wait()
+
+				stats := w.Stats()
+				if got, want := uint64(out.lines())+stats.Dropped, uint64(writers*perWriter); got != want {
+					t.Fatalf("round %d: written %d + dropped %d = %d, want %d", round, out.lines(), stats.Dropped, got, want)
+				}
+				if stats.Pending != 0 {
+					t.Fatalf("round %d: %d entries left in the queue", round, stats.Pending)
+				}
+			}
+		})
+	}
+}
@@ -1,36 +1,28 @@
 package zlog
 
-import (
-	"fmt"
-)
-
 type LoggerConfig struct {
-	Level      Level             `yaml:"level"`
-	Output     string            `yaml:"output"` // file、console、both
-	Format     string            `yaml:"format"` // json、console
-	FilePath   string            `yaml:"file_path"`
-	MaxSize    int               `yaml:"max_size"`
-	MaxBackups int               `yaml:"max_backups"`
-	MaxAge     int               `yaml:"max_age"`
-	Compress   bool              `yaml:"compress"`
-	Sampling   bool              `yaml:"sampling"`
-	Fields     map[string]string `yaml:"fields"`
-}
-
-func (c *LoggerConfig) Validate() error {
-	if c.MaxSize <= 0 {
-		c.MaxSize = 100
-	}
-	if c.MaxBackups < 0 {
-		c.MaxBackups = 10
-	}
-	if c.MaxAge < 0 {
-		c.MaxAge = 30
-	}
-	if (c.Output == "file" || c.Output == "both") && c.FilePath == "" {
-		return fmt.Errorf("FilePath is required when Output='file'")
-	}
-	return nil
+	Level            Level             `yaml:"level"`
+	Output           string            `yaml:"output"` // file、console、both
+	Format           string            `yaml:"format"` // json、console、logfmt
+	FilePath         string            `yaml:"file_path"`
+	MaxSize          int               `yaml:"max_size"`
+	MaxBackups       int               `yaml:"max_backups"`
+	MaxAge           int               `yaml:"max_age"`
+	Compress         bool              `yaml:"compress"`
+	Sampling         bool              `yaml:"sampling"`
+	Sampler          *SamplingConfig   `yaml:"sampler"` // sampling and rate-limit parameters; setting it enables sampling
+	Fields           map[string]string `yaml:"fields"`
+	SpanEvents       bool              `yaml:"span_events"`       // record *Ctx entries as events on the active OpenTelemetry span
+	Redact           *RedactConfig     `yaml:"redact"`            // mask sensitive keys and values; nil disables redaction
+	Async            *AsyncConfig      `yaml:"async"`             // buffered asynchronous writes; nil writes synchronously
+	Outputs          []OutputConfig    `yaml:"outputs"`           // per-output sinks; when set, replaces Output/Format/FilePath
+	DedupeStacktrace bool              `yaml:"dedupe_stacktrace"` // omit the logger stack trace when an error field carries its own (see Wrap)
+	Rotation         *RotationConfig   `yaml:"rotation"`          // time-based rotation and retention of log files; nil rotates by size only
+	Encoding         *EncodingConfig   `yaml:"encoding"`          // key names, time format, console layout and color; nil keeps the defaults
+	Modules          map[string]Level  `yaml:"modules"`           // levels of named loggers by name or pattern, e.g. payments: debug, "payments.*": warn
+	Fatal            *FatalConfig      `yaml:"fatal"`             // exit code, flush timeout and crash dump for fatal entries
+	Recent           *RecentConfig     `yaml:"recent"`            // keep the latest entries of all levels in memory, see RecentHandler and FlushRecent
+	Strict           bool              `yaml:"strict"`            // fail on any problem reported by Validate instead of falling back to defaults
 }
 
 func DefaultConfig() LoggerConfig {
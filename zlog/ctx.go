@@ -3,6 +3,7 @@ package zlog
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -14,9 +15,9 @@ const (
 	TraceIDKey   ctxKey = "trace_id"
 )
 
-func loggerWithContext(ctx context.Context) *zap.Logger {
-	logger := Logger()
-
+// contextFields collects request, user and trace identifiers carried by ctx.
+// An OpenTelemetry span context takes precedence over a plain TraceIDKey value.
+func contextFields(ctx context.Context) []zap.Field {
 	var extraFields []zap.Field
 
 	if reqID, ok := ctx.Value(RequestIDKey).(string); ok && reqID != "" {
@@ -25,107 +26,214 @@ func loggerWithContext(ctx context.Context) *zap.Logger {
 	if userID, ok := ctx.Value(UserIDKey).(string); ok && userID != "" {
 		extraFields = append(extraFields, zap.String("user_id", userID))
 	}
-	if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		extraFields = append(extraFields,
+			zap.String("trace_id", sc.TraceID().String()),
+			zap.String("span_id", sc.SpanID().String()),
+		)
+	} else if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
 		extraFields = append(extraFields, zap.String("trace_id", traceID))
 	}
+	return extraFields
+}
+
+// ContextFields returns the request, user and trace identifiers carried by
+// ctx as fields, as the *Ctx methods add them. It lets adapters built on a
+// plain zap.Logger log the same identifiers.
+func ContextFields(ctx context.Context) []Field {
+	return contextFields(ctx)
+}
 
-	if len(extraFields) > 0 {
-		logger = logger.With(extraFields...)
+// withContext returns the method logger with the identifiers carried by ctx attached.
+func (l *Logger) withContext(ctx context.Context) *zap.Logger {
+	if extraFields := contextFields(ctx); len(extraFields) > 0 {
+		return l.zl.With(extraFields...)
 	}
-	return logger
+	return l.zl
 }
 
-func sugarWithContext(ctx context.Context) *zap.SugaredLogger {
-	logger := Logger()
+func (l *Logger) sugarWithContext(ctx context.Context) *zap.SugaredLogger {
+	return l.withContext(ctx).Sugar()
+}
 
-	var extraFields []zap.Field
+func (l *Logger) DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(DebugLevel, msg, fields)
+	l.addSpanEvent(ctx, DebugLevel, msg, fields)
+	l.withContext(ctx).Debug(msg, fields...)
+}
 
-	if reqID, ok := ctx.Value(RequestIDKey).(string); ok && reqID != "" {
-		extraFields = append(extraFields, zap.String("request_id", reqID))
-	}
-	if userID, ok := ctx.Value(UserIDKey).(string); ok && userID != "" {
-		extraFields = append(extraFields, zap.String("user_id", userID))
-	}
-	if traceID, ok := ctx.Value(TraceIDKey).(string); ok && traceID != "" {
-		extraFields = append(extraFields, zap.String("trace_id", traceID))
-	}
+func (l *Logger) InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(InfoLevel, msg, fields)
+	l.addSpanEvent(ctx, InfoLevel, msg, fields)
+	l.withContext(ctx).Info(msg, fields...)
+}
 
-	if len(extraFields) > 0 {
-		logger = logger.With(extraFields...)
-	}
-	return logger.Sugar()
+func (l *Logger) WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(WarnLevel, msg, fields)
+	l.addSpanEvent(ctx, WarnLevel, msg, fields)
+	l.withContext(ctx).Warn(msg, fields...)
+}
+
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(ErrorLevel, msg, fields)
+	l.addSpanEvent(ctx, ErrorLevel, msg, fields)
+	l.withContext(ctx).Error(msg, fields...)
+}
+
+func (l *Logger) PanicCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(PanicLevel, msg, fields)
+	l.addSpanEvent(ctx, PanicLevel, msg, fields)
+	l.withContext(ctx).Panic(msg, fields...)
+}
+
+func (l *Logger) FatalCtx(ctx context.Context, msg string, fields ...Field) {
+	l.executeHooks(FatalLevel, msg, fields)
+	l.addSpanEvent(ctx, FatalLevel, msg, fields)
+	l.withContext(ctx).Fatal(msg, fields...)
+}
+
+func (l *Logger) DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(DebugLevel, format, args)
+	l.addSpanEventf(ctx, DebugLevel, format, args)
+	l.sugarWithContext(ctx).Debugf(format, args...)
 }
 
+func (l *Logger) InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(InfoLevel, format, args)
+	l.addSpanEventf(ctx, InfoLevel, format, args)
+	l.sugarWithContext(ctx).Infof(format, args...)
+}
+
+func (l *Logger) WarnfCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(WarnLevel, format, args)
+	l.addSpanEventf(ctx, WarnLevel, format, args)
+	l.sugarWithContext(ctx).Warnf(format, args...)
+}
+
+func (l *Logger) ErrorfCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(ErrorLevel, format, args)
+	l.addSpanEventf(ctx, ErrorLevel, format, args)
+	l.sugarWithContext(ctx).Errorf(format, args...)
+}
+
+func (l *Logger) PanicfCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(PanicLevel, format, args)
+	l.addSpanEventf(ctx, PanicLevel, format, args)
+	l.sugarWithContext(ctx).Panicf(format, args...)
+}
+
+func (l *Logger) FatalfCtx(ctx context.Context, format string, args ...interface{}) {
+	l.executeHooksf(FatalLevel, format, args)
+	l.addSpanEventf(ctx, FatalLevel, format, args)
+	l.sugarWithContext(ctx).Fatalf(format, args...)
+}
+
+func (l *Logger) DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(DebugLevel, msg, nil)
+	l.addSpanEventw(ctx, DebugLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Debugw(msg, keysAndValues...)
+}
+
+func (l *Logger) InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(InfoLevel, msg, nil)
+	l.addSpanEventw(ctx, InfoLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Infow(msg, keysAndValues...)
+}
+
+func (l *Logger) WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(WarnLevel, msg, nil)
+	l.addSpanEventw(ctx, WarnLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Warnw(msg, keysAndValues...)
+}
+
+func (l *Logger) ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(ErrorLevel, msg, nil)
+	l.addSpanEventw(ctx, ErrorLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Errorw(msg, keysAndValues...)
+}
+
+func (l *Logger) PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(PanicLevel, msg, nil)
+	l.addSpanEventw(ctx, PanicLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Panicw(msg, keysAndValues...)
+}
+
+func (l *Logger) FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.executeHooks(FatalLevel, msg, nil)
+	l.addSpanEventw(ctx, FatalLevel, msg, keysAndValues)
+	l.sugarWithContext(ctx).Fatalw(msg, keysAndValues...)
+}
+
+// ========== Package-level context logging ==========
+
 func DebugCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Debug(msg, fields...)
+	pkg().DebugCtx(ctx, msg, fields...)
 }
 
 func InfoCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Info(msg, fields...)
+	pkg().InfoCtx(ctx, msg, fields...)
 }
 
 func WarnCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Warn(msg, fields...)
+	pkg().WarnCtx(ctx, msg, fields...)
 }
 
 func ErrorCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Error(msg, fields...)
+	pkg().ErrorCtx(ctx, msg, fields...)
 }
 
 func PanicCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Panic(msg, fields...)
+	pkg().PanicCtx(ctx, msg, fields...)
 }
 
 func FatalCtx(ctx context.Context, msg string, fields ...Field) {
-	loggerWithContext(ctx).Fatal(msg, fields...)
+	pkg().FatalCtx(ctx, msg, fields...)
 }
 
-
 func DebugfCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Debugf(format, args...)
+	pkg().DebugfCtx(ctx, format, args...)
 }
 
 func InfofCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Infof(format, args...)
+	pkg().InfofCtx(ctx, format, args...)
 }
 
 func WarnfCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Warnf(format, args...)
+	pkg().WarnfCtx(ctx, format, args...)
 }
 
 func ErrorfCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Errorf(format, args...)
+	pkg().ErrorfCtx(ctx, format, args...)
 }
 
 func PanicfCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Panicf(format, args...)
+	pkg().PanicfCtx(ctx, format, args...)
 }
 
 func FatalfCtx(ctx context.Context, format string, args ...interface{}) {
-	sugarWithContext(ctx).Fatalf(format, args...)
+	pkg().FatalfCtx(ctx, format, args...)
 }
 
-
 func DebugwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Debugw(msg, keysAndValues...)
+	pkg().DebugwCtx(ctx, msg, keysAndValues...)
 }
 
 func InfowCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Infow(msg, keysAndValues...)
+	pkg().InfowCtx(ctx, msg, keysAndValues...)
 }
 
 func WarnwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Warnw(msg, keysAndValues...)
+	pkg().WarnwCtx(ctx, msg, keysAndValues...)
 }
 
 func ErrorwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Errorw(msg, keysAndValues...)
+	pkg().ErrorwCtx(ctx, msg, keysAndValues...)
 }
 
 func PanicwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Panicw(msg, keysAndValues...)
+	pkg().PanicwCtx(ctx, msg, keysAndValues...)
 }
 
 func FatalwCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
-	sugarWithContext(ctx).Fatalw(msg, keysAndValues...)
+	pkg().FatalwCtx(ctx, msg, keysAndValues...)
 }
@@ -0,0 +1,220 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentFilter selects entries kept by LoggerConfig.Recent.
+type RecentFilter struct {
+	Level     Level  // lowest level, empty for all
+	RequestID string // request_id field, empty for any
+	TraceID   string // trace_id field, empty for any
+	Limit     int    // latest entries after filtering, 0 for all
+}
+
+func (f RecentFilter) match(e *recentEntry) bool {
+	if f.Level != "" && e.ent.Level < f.Level.toZapCoreLevel() {
+		return false
+	}
+	if f.RequestID != "" && stringField(e.fields, "request_id") != f.RequestID {
+		return false
+	}
+	if f.TraceID != "" && stringField(e.fields, "trace_id") != f.TraceID {
+		return false
+	}
+	return true
+}
+
+// stringField returns the last string field named key, as added by With and *Ctx.
+func stringField(fields []Field, key string) string {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key && fields[i].Type == zapcore.StringType {
+			return fields[i].String
+		}
+	}
+	return ""
+}
+
+// filterRecent returns the entries matching f, oldest first.
+func filterRecent(entries []*recentEntry, f RecentFilter) []*recentEntry {
+	out := entries[:0:0]
+	for _, e := range entries {
+		if f.match(e) {
+			out = append(out, e)
+		}
+	}
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out
+}
+
+// encodeRecent writes entries as JSON, one object per line, or as a JSON
+// array when array is set.
+func encodeRecent(w io.Writer, entries []*recentEntry, array bool) error {
+	encCfg, _ := (*EncodingConfig)(nil).encoderConfig()
+	enc := zapcore.NewJSONEncoder(encCfg)
+	sep := "[\n"
+	for _, e := range entries {
+		buf, err := enc.EncodeEntry(e.ent, e.fields)
+		if err != nil {
+			continue
+		}
+		line := buf.Bytes()
+		if array {
+			line = bytes.TrimRight(line, "\n")
+			_, err = io.WriteString(w, sep)
+			sep = ",\n"
+		}
+		if err == nil {
+			_, err = w.Write(line)
+		}
+		buf.Free()
+		if err != nil {
+			return err
+		}
+	}
+	if !array {
+		return nil
+	}
+	if sep == "[\n" {
+		_, err := io.WriteString(w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(w, "\n]\n")
+	return err
+}
+
+// DumpRecent writes the kept entries matching f to w as a JSON array,
+// oldest first. It fails when LoggerConfig.Recent is not set.
+func (l *Logger) DumpRecent(w io.Writer, f RecentFilter) error {
+	if l.parts.recent == nil {
+		return fmt.Errorf("recent entries are not kept, set LoggerConfig.Recent")
+	}
+	return encodeRecent(w, filterRecent(l.parts.recent.snapshot(), f), true)
+}
+
+// RecentHandler returns an http.Handler serving the kept entries as a JSON
+// array, oldest first. The query parameters level, request_id, trace_id and
+// limit set the fields of RecentFilter:
+//
+//	GET /debug/logs?level=debug&request_id=abc&limit=100
+//
+// The entries may hold anything the application logs; mount the handler on
+// an internal address only.
+func (l *Logger) RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if l.parts.recent == nil {
+			http.Error(w, "recent entries are not kept", http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		f := RecentFilter{
+			Level:     Level(q.Get("level")),
+			RequestID: q.Get("request_id"),
+			TraceID:   q.Get("trace_id"),
+		}
+		if f.Level != "" && !f.Level.Valid() {
+			http.Error(w, fmt.Sprintf("unknown level %q", f.Level), http.StatusBadRequest)
+			return
+		}
+		if s := q.Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 {
+				http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+				return
+			}
+			f.Limit = n
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = encodeRecent(w, filterRecent(l.parts.recent.snapshot(), f), true)
+	})
+}
+
+// FlushRecent writes the kept entries matching f that no output accepted
+// when they were logged, such as debug entries below the output level, to
+// the outputs, bypassing their level filters. Each entry is flushed at most
+// once. It returns the number of entries written, 0 when LoggerConfig.Recent
+// is not set. Together with a debug Recent level this logs the debug context
+// of a request only when it fails:
+//
+//	if err != nil {
+//		logger.ErrorCtx(ctx, "checkout failed", zlog.Err(err))
+//		logger.FlushRecentCtx(ctx)
+//	}
+func (l *Logger) FlushRecent(f RecentFilter) int {
+	if l.parts.recent == nil || l.parts.outputs == nil {
+		return 0
+	}
+	n := 0
+	for _, e := range filterRecent(l.parts.recent.snapshot(), f) {
+		if e.written || !e.flushed.CompareAndSwap(false, true) {
+			continue
+		}
+		if err := l.parts.outputs.Write(e.ent, e.fields); err != nil {
+			fmt.Fprintf(os.Stderr, "[zlog] flush recent: %v\n", err)
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// FlushRecentCtx flushes the kept entries of the request carried by ctx, by
+// its request ID or else its trace ID, see FlushRecent. Without either it
+// flushes nothing.
+func (l *Logger) FlushRecentCtx(ctx context.Context) int {
+	var f RecentFilter
+	for _, field := range contextFields(ctx) {
+		switch field.Key {
+		case "request_id":
+			f.RequestID = field.String
+		case "trace_id":
+			f.TraceID = field.String
+		}
+	}
+	if f.RequestID != "" {
+		f.TraceID = ""
+	} else if f.TraceID == "" {
+		return 0
+	}
+	return l.FlushRecent(f)
+}
+
+// DumpRecent writes the entries kept by the default instance, see Logger.DumpRecent.
+func DumpRecent(w io.Writer, f RecentFilter) error {
+	return L().DumpRecent(w, f)
+}
+
+// RecentHandler serves the entries kept by the default instance, see
+// Logger.RecentHandler. It follows ReplaceGlobals.
+func RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		L().RecentHandler().ServeHTTP(w, r)
+	})
+}
+
+// FlushRecent flushes entries kept by the default instance, see Logger.FlushRecent.
+func FlushRecent(f RecentFilter) int {
+	return L().FlushRecent(f)
+}
+
+// FlushRecentCtx flushes the entries of the request carried by ctx from the
+// default instance, see Logger.FlushRecentCtx.
+func FlushRecentCtx(ctx context.Context) int {
+	return L().FlushRecentCtx(ctx)
+}
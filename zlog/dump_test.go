@@ -0,0 +1,151 @@
+package zlog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recentLogger returns a logger keeping recent entries of all levels while
+// its output only accepts info and above.
+func recentLogger(t *testing.T) (*Logger, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	l, err := NewWithCoreConfig(core, LoggerConfig{Level: DebugLevel, Recent: &RecentConfig{Size: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, logs
+}
+
+func messages(logs []observer.LoggedEntry) []string {
+	var out []string
+	for _, e := range logs {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+func TestRecentHandler(t *testing.T) {
+	l, _ := recentLogger(t)
+	r1 := l.With(String("request_id", "r1"))
+	r1.Debug("a")
+	l.With(String("request_id", "r2")).Info("b")
+	r1.Warn("c")
+	l.Error("d")
+	h := l.RecentHandler()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "a b c d"},
+		{"level=info", "b c d"},
+		{"level=warn", "c d"},
+		{"request_id=r1", "a c"},
+		{"request_id=r3", ""},
+		{"limit=2", "c d"},
+		{"limit=0", "a b c d"},
+		{"request_id=r1&limit=1", "c"},
+		{"level=info&request_id=r1", "c"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status %d: %s", tt.query, rec.Code, rec.Body)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%q: Content-Type = %q", tt.query, ct)
+		}
+		var entries []map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("%q: %v\n%s", tt.query, err, rec.Body)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e["msg"].(string))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%q: entries %v, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"level=loud", "limit=-1", "limit=ten"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/logs?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", query, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/logs", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST: status %d, Allow %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	core, _ := observer.New(zapcore.InfoLevel)
+	rec = httptest.NewRecorder()
+	NewWithCore(core).RecentHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without Recent: status %d, want 404", rec.Code)
+	}
+}
+
+func TestFlushRecent(t *testing.T) {
+	l, logs := recentLogger(t)
+	r1 := l.With(String("request_id", "r1"))
+	r1.Debug("debug r1")
+	r1.Info("info r1")
+	l.With(String("request_id", "r2")).Debug("debug r2")
+	r1.Debug("debug r1 again")
+
+	if n := l.FlushRecent(RecentFilter{RequestID: "r1"}); n != 2 {
+		t.Errorf("FlushRecent = %d, want 2", n)
+	}
+	want := "info r1,debug r1,debug r1 again"
+	if got := strings.Join(messages(logs.All()), ","); got != want {
+		t.Errorf("outputs got %s, want %s", got, want)
+	}
+	if n := l.FlushRecent(RecentFilter{RequestID: "r1"}); n != 0 {
+		t.Errorf("second FlushRecent = %d, want 0", n)
+	}
+	if n := l.FlushRecent(RecentFilter{}); n != 1 {
+		t.Errorf("FlushRecent of all = %d, want only debug r2", n)
+	}
+	if logs.Len() != 4 {
+		t.Errorf("outputs got %v", messages(logs.All()))
+	}
+
+	core, _ := observer.New(zapcore.InfoLevel)
+	if n := NewWithCore(core).FlushRecent(RecentFilter{}); n != 0 {
+		t.Errorf("without Recent: FlushRecent = %d", n)
+	}
+}
+
+func TestFlushRecentCtx(t *testing.T) {
+	l, logs := recentLogger(t)
+	both := context.WithValue(context.WithValue(context.Background(), RequestIDKey, "r1"), TraceIDKey, "t1")
+	traceOnly := context.WithValue(context.Background(), TraceIDKey, "t1")
+
+	l.DebugCtx(both, "request")
+	l.DebugCtx(traceOnly, "trace")
+
+	// The request ID wins, so entries sharing only the trace stay kept.
+	if n := l.FlushRecentCtx(both); n != 1 || logs.FilterMessage("request").Len() != 1 {
+		t.Errorf("FlushRecentCtx by request = %d, outputs %v", n, messages(logs.All()))
+	}
+	if n := l.FlushRecentCtx(context.Background()); n != 0 {
+		t.Errorf("FlushRecentCtx without IDs = %d", n)
+	}
+	if n := l.FlushRecentCtx(traceOnly); n != 1 || logs.FilterMessage("trace").Len() != 1 {
+		t.Errorf("FlushRecentCtx by trace = %d, outputs %v", n, messages(logs.All()))
+	}
+}
@@ -0,0 +1,228 @@
+package zlog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var testEntry = zapcore.Entry{
+	Level:      zapcore.WarnLevel,
+	Time:       time.Date(2026, 10, 17, 8, 30, 0, 0, time.UTC),
+	LoggerName: "api",
+	Message:    "slow request",
+	Caller:     zapcore.NewEntryCaller(0, "/src/shop/handler.go", 42, true),
+}
+
+// testEncoderConfig returns the default encoder configuration in UTC.
+func testEncoderConfig(t *testing.T, e *EncodingConfig) zapcore.EncoderConfig {
+	t.Helper()
+	if e == nil {
+		e = &EncodingConfig{}
+	}
+	e.Timezone = "UTC"
+	cfg, err := e.encoderConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func encodeLine(t *testing.T, enc zapcore.Encoder, ent zapcore.Entry, fields ...Field) string {
+	t.Helper()
+	buf, err := enc.EncodeEntry(ent, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	enc := NewLogfmtEncoder(testEncoderConfig(t, nil))
+	head := `ts=2026-10-17T08:30:00.000Z level=warn logger=api caller=shop/handler.go:42 msg="slow request"`
+
+	tests := []struct {
+		name   string
+		fields []Field
+		want   string
+	}{
+		{"plain", []Field{String("path", "/orders"), Int("status", 200), Bool("ok", true)}, ` path=/orders status=200 ok=true`},
+		{"empty", []Field{String("user", "")}, ` user=""`},
+		{"space", []Field{String("q", "a b")}, ` q="a b"`},
+		{"quote", []Field{String("q", `say "hi"`)}, ` q="say \"hi\""`},
+		{"equals", []Field{String("q", "a=b")}, ` q="a=b"`},
+		{"backslash", []Field{String("path", `C:\tmp`)}, ` path="C:\\tmp"`},
+		{"newline", []Field{String("q", "a\nb")}, ` q="a\nb"`},
+		{"unicode", []Field{String("city", "北京")}, ` city=北京`},
+		{"key", []Field{String("bad key=\"x\"", "v")}, ` bad_key__x_=v`},
+		{"float", []Field{Float64("ratio", 0.5), Float64("inf", math.Inf(1)), Float64("nan", math.NaN())}, ` ratio=0.5 inf=+Inf nan=NaN`},
+		{"duration", []Field{Duration("took", 1500*time.Millisecond)}, ` took=1.5`},
+		{"object", []Field{Any("user", map[string]int{"id": 7})}, ` user="{\"id\":7}"`},
+		{"array", []Field{Strings("tags", []string{"a", "b c"})}, ` tags="[\"a\",\"b c\"]"`},
+		{"error", []Field{zap.Error(errors.New("disk full"))}, ` error="disk full"`},
+		{"namespace", []Field{String("a", "1"), Namespace("http"), Int("status", 500), Namespace("resp"), Int("bytes", 10)}, ` a=1 http.status=500 http.resp.bytes=10`},
+	}
+	for _, tt := range tests {
+		if got := encodeLine(t, enc, testEntry, tt.fields...); got != head+tt.want+"\n" {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, head+tt.want+"\n")
+		}
+	}
+
+	// Context added by With, including an open namespace, precedes the entry's fields.
+	ctx := enc.Clone()
+	String("service", "shop").AddTo(ctx)
+	Namespace("req").AddTo(ctx)
+	String("id", "r1").AddTo(ctx)
+	want := head + ` service=shop req.id=r1 req.status=200` + "\n"
+	if got := encodeLine(t, ctx, testEntry, Int("status", 200)); got != want {
+		t.Errorf("With context:\n got %q\nwant %q", got, want)
+	}
+	if got := encodeLine(t, enc, testEntry); got != head+"\n" {
+		t.Errorf("With leaked into the parent encoder: %q", got)
+	}
+
+	stack := testEntry
+	stack.Stack = "main.main\n\tmain.go:1"
+	if got := encodeLine(t, enc, stack); got != head+` stacktrace="main.main\n\tmain.go:1"`+"\n" {
+		t.Errorf("stack trace: %q", got)
+	}
+}
+
+func TestLogfmtOmitKey(t *testing.T) {
+	cfg := testEncoderConfig(t, &EncodingConfig{
+		TimeKey: OmitKey, CallerKey: OmitKey, NameKey: OmitKey, LevelKey: "severity", MessageKey: "message",
+	})
+	got := encodeLine(t, NewLogfmtEncoder(cfg), testEntry, Int("n", 1))
+	if want := `severity=warn message="slow request" n=1` + "\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLayoutEncoder(t *testing.T) {
+	cfg := testEncoderConfig(t, nil)
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	noCaller := testEntry
+	noCaller.Caller = zapcore.EntryCaller{}
+	noCaller.LoggerName = ""
+
+	tests := []struct {
+		layout string
+		ent    zapcore.Entry
+		fields []Field
+		want   string
+	}{
+		{
+			"{time} [{level}] {caller} {msg} {fields}", testEntry, []Field{Int("status", 504)},
+			`2026-10-17T08:30:00.000Z [WARN] shop/handler.go:42 slow request {"status":504}`,
+		},
+		// Aliases render the same parts.
+		{"{ts} {name} {message}", testEntry, nil, `2026-10-17T08:30:00.000Z api slow request`},
+		// Empty placeholders drop the spaces after them and trailing spaces go.
+		{"{level} {logger} {caller} {msg} {fields}", noCaller, nil, `WARN slow request`},
+		{"{level} | {function} | {msg}", noCaller, nil, `WARN | | slow request`},
+		{"[{level}]{msg}", testEntry, nil, `[WARN]slow request`},
+	}
+	for _, tt := range tests {
+		enc, err := NewLayoutEncoder(tt.layout, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := encodeLine(t, enc, tt.ent, tt.fields...); got != tt.want+"\n" {
+			t.Errorf("%s:\n got %q\nwant %q", tt.layout, got, tt.want+"\n")
+		}
+	}
+
+	enc, _ := NewLayoutEncoder("{level} {msg} {fields}", cfg)
+	ctx := enc.Clone()
+	String("service", "shop").AddTo(ctx)
+	if got := encodeLine(t, ctx, testEntry, Int("n", 1)); got != `WARN slow request {"service":"shop","n":1}`+"\n" {
+		t.Errorf("With context: %q", got)
+	}
+}
+
+func TestLayoutEncoderStacktrace(t *testing.T) {
+	cfg := testEncoderConfig(t, nil)
+	cfg.EncodeLevel = zapcore.CapitalLevelEncoder
+	ent := testEntry
+	ent.Stack = "main.main\n\tmain.go:1"
+
+	enc, _ := NewLayoutEncoder("{level} {msg}", cfg)
+	if got := encodeLine(t, enc, ent); got != "WARN slow request\nmain.main\n\tmain.go:1\n" {
+		t.Errorf("trailing stack: %q", got)
+	}
+	enc, _ = NewLayoutEncoder("{level} {msg} <{stacktrace}>", cfg)
+	if got := encodeLine(t, enc, ent); got != "WARN slow request <main.main\n\tmain.go:1>\n" {
+		t.Errorf("{stacktrace} placeholder: %q", got)
+	}
+	cfg.StacktraceKey = zapcore.OmitKey
+	enc, _ = NewLayoutEncoder("{level} {msg}", cfg)
+	if got := encodeLine(t, enc, ent); got != "WARN slow request\n" {
+		t.Errorf("omitted stack: %q", got)
+	}
+}
+
+func TestParseLayoutErrors(t *testing.T) {
+	for layout, want := range map[string]string{
+		"{time} {msg":     "unclosed placeholder",
+		"{time} {thread}": "unknown placeholder {thread}",
+		"{}":              "unknown placeholder {}",
+	} {
+		if _, err := parseLayout(layout); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseLayout(%q) = %v, want %q", layout, err, want)
+		}
+	}
+	parts, err := parseLayout("plain text")
+	if err != nil || len(parts) != 1 || parts[0].literal != "plain text" {
+		t.Errorf("literal layout = %v, %v", parts, err)
+	}
+}
+
+func TestColorModes(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	for _, out := range []*os.File{f, w} {
+		tests := []struct {
+			enc  *EncodingConfig
+			want bool
+		}{
+			{nil, true},
+			{&EncodingConfig{}, true},
+			{&EncodingConfig{Color: ColorAlways}, true},
+			{&EncodingConfig{Color: ColorAuto}, false},
+			{&EncodingConfig{Color: ColorNever}, false},
+		}
+		for _, tt := range tests {
+			if got := tt.enc.useColor(out); got != tt.want {
+				t.Errorf("%s: useColor(%+v) = %v, want %v", out.Name(), tt.enc, got, tt.want)
+			}
+		}
+	}
+
+	// Colored levels carry ANSI escapes, uncolored ones do not.
+	for color, want := range map[bool]bool{true: true, false: false} {
+		enc, err := newEncoder(FormatConsole, testEncoderConfig(t, nil), color, "{level} {msg}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(encodeLine(t, enc, testEntry), "\x1b["); got != want {
+			t.Errorf("color=%v: escapes present = %v", color, got)
+		}
+	}
+}
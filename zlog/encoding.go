@@ -0,0 +1,148 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Time formats for EncodingConfig.TimeFormat. Any other value is used as a
+// Go time layout, e.g. "2006-01-02 15:04:05.000".
+const (
+	TimeISO8601     = "iso8601"      // 2006-01-02T15:04:05.000Z0700 (default)
+	TimeRFC3339     = "rfc3339"      // 2006-01-02T15:04:05Z07:00
+	TimeRFC3339Nano = "rfc3339nano"  // 2006-01-02T15:04:05.999999999Z07:00
+	TimeEpoch       = "epoch"        // seconds since the Unix epoch, as a float
+	TimeEpochMillis = "epoch_millis" // milliseconds since the Unix epoch
+	TimeEpochNanos  = "epoch_nanos"  // nanoseconds since the Unix epoch
+)
+
+// Color modes for EncodingConfig.Color.
+const (
+	ColorAlways = "always" // always color console levels on stdout/stderr (default)
+	ColorAuto   = "auto"   // color only when stdout/stderr is a terminal
+	ColorNever  = "never"
+)
+
+// OmitKey as a key name in EncodingConfig leaves that part out of the entry.
+const OmitKey = "-"
+
+// EncodingConfig customizes how entries are encoded. Empty values keep the
+// defaults shown in the comments.
+type EncodingConfig struct {
+	TimeKey       string `yaml:"time_key"`       // ts
+	LevelKey      string `yaml:"level_key"`      // level
+	NameKey       string `yaml:"name_key"`       // logger
+	CallerKey     string `yaml:"caller_key"`     // caller
+	FunctionKey   string `yaml:"function_key"`   // omitted
+	MessageKey    string `yaml:"message_key"`    // msg
+	StacktraceKey string `yaml:"stacktrace_key"` // stacktrace
+
+	TimeFormat string `yaml:"time_format"` // iso8601、rfc3339、rfc3339nano、epoch、epoch_millis、epoch_nanos or a Go layout
+	Timezone   string `yaml:"timezone"`    // IANA name such as UTC or Asia/Shanghai; default local time
+
+	// Layout renders console output through a template such as
+	// "{time} [{level}] {caller} {msg} {fields}", see NewLayoutEncoder.
+	Layout string `yaml:"layout"`
+	Color  string `yaml:"color"` // always (default), auto, never
+}
+
+// encoderConfig returns the zap encoder configuration for c; a nil c yields
+// the defaults.
+func (c *EncodingConfig) encoderConfig() (zapcore.EncoderConfig, error) {
+	var e EncodingConfig
+	if c != nil {
+		e = *c
+	}
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:        keyName(e.TimeKey, "ts"),
+		LevelKey:       keyName(e.LevelKey, "level"),
+		NameKey:        keyName(e.NameKey, "logger"),
+		CallerKey:      keyName(e.CallerKey, "caller"),
+		FunctionKey:    keyName(e.FunctionKey, zapcore.OmitKey),
+		MessageKey:     keyName(e.MessageKey, "msg"),
+		StacktraceKey:  keyName(e.StacktraceKey, "stacktrace"),
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+	var loc *time.Location
+	if e.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(e.Timezone); err != nil {
+			return encCfg, fmt.Errorf("invalid encoding timezone %q: %w", e.Timezone, err)
+		}
+	}
+	encCfg.EncodeTime = timeEncoder(e.TimeFormat, loc)
+	return encCfg, nil
+}
+
+func keyName(key, def string) string {
+	switch key {
+	case "":
+		return def
+	case OmitKey:
+		return zapcore.OmitKey
+	}
+	return key
+}
+
+// timeEncoder formats times according to format in loc (nil for local time).
+func timeEncoder(format string, loc *time.Location) zapcore.TimeEncoder {
+	in := func(t time.Time) time.Time {
+		if loc != nil {
+			return t.In(loc)
+		}
+		return t
+	}
+	var layout string
+	switch format {
+	case "", TimeISO8601:
+		layout = "2006-01-02T15:04:05.000Z0700"
+	case TimeRFC3339:
+		layout = time.RFC3339
+	case TimeRFC3339Nano:
+		layout = time.RFC3339Nano
+	case TimeEpoch:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendFloat64(float64(t.UnixNano()) / float64(time.Second))
+		}
+	case TimeEpochMillis:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt64(t.UnixMilli())
+		}
+	case TimeEpochNanos:
+		return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendInt64(t.UnixNano())
+		}
+	default:
+		layout = format
+	}
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		enc.AppendString(in(t).Format(layout))
+	}
+}
+
+// useColor reports whether console output to f should be colored.
+func (c *EncodingConfig) useColor(f *os.File) bool {
+	if c == nil {
+		return true
+	}
+	switch c.Color {
+	case ColorNever:
+		return false
+	case ColorAuto:
+		return IsTerminal(f)
+	}
+	return true
+}
+
+// IsTerminal reports whether f is a character device such as a terminal,
+// the check behind ColorAuto.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
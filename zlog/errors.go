@@ -0,0 +1,283 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StackTracer is implemented by errors that carry the stack trace of the
+// place where they were created or wrapped.
+type StackTracer interface {
+	StackTrace() string
+}
+
+// stackError annotates an error with a message and the caller's stack.
+type stackError struct {
+	err   error
+	msg   string
+	stack []uintptr
+}
+
+// WithStack records the caller's stack trace on err. It returns nil for a nil
+// error and err itself when it already carries a stack.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	var st StackTracer
+	if errors.As(err, &st) {
+		return err
+	}
+	return &stackError{err: err, stack: callers()}
+}
+
+// Wrap returns an error "msg: err" that unwraps to err and records the caller's
+// stack trace unless err already carries one. It returns nil for a nil error.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	se := &stackError{err: err, msg: msg}
+	var st StackTracer
+	if !errors.As(err, &st) {
+		se.stack = callers()
+	}
+	return se
+}
+
+// Wrapf is Wrap with a formatted message.
+func Wrapf(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	se := &stackError{err: err, msg: fmt.Sprintf(format, args...)}
+	var st StackTracer
+	if !errors.As(err, &st) {
+		se.stack = callers()
+	}
+	return se
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// StackTrace returns the recorded stack, or the one of the wrapped error.
+func (e *stackError) StackTrace() string {
+	if len(e.stack) == 0 {
+		var st StackTracer
+		if errors.As(e.err, &st) {
+			return st.StackTrace()
+		}
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.goexit" {
+			break
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// Format prints the stack with %+v, which zap.Error reports as errorVerbose.
+func (e *stackError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		io.WriteString(s, e.Error())
+		if len(e.stack) > 0 {
+			io.WriteString(s, "\n")
+			io.WriteString(s, e.StackTrace())
+		}
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		io.WriteString(s, e.Error())
+	}
+}
+
+// Err builds an "error" field recording the message, type, unwrap chain
+// and, when available, the stack trace captured by Wrap or WithStack.
+// A nil error adds no field.
+func Err(err error) Field {
+	return NamedErr("error", err)
+}
+
+// NamedErr is Err with a custom key.
+func NamedErr(key string, err error) Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, errorObject{err: err})
+}
+
+// errorObject marshals an error with its chain and stack.
+type errorObject struct {
+	err error
+}
+
+func (o errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("msg", o.err.Error())
+	enc.AddString("type", errorType(o.err))
+	if chain := errorChain(o.err); len(chain) > 1 {
+		_ = enc.AddArray("chain", errorChainArray(chain))
+	}
+	if stack := errorStack(o.err); stack != "" {
+		enc.AddString("stack", stack)
+	}
+	return nil
+}
+
+// errorChainArray lists the errors of an unwrap chain, outermost first.
+type errorChainArray []error
+
+func (a errorChainArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range a {
+		_ = enc.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("msg", err.Error())
+			enc.AddString("type", errorType(err))
+			return nil
+		}))
+	}
+	return nil
+}
+
+// errorChain follows Unwrap, including errors joined with errors.Join.
+func errorChain(err error) []error {
+	var chain []error
+	queue := []error{err}
+	for len(queue) > 0 && len(chain) < 32 {
+		e := queue[0]
+		queue = queue[1:]
+		chain = append(chain, e)
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			if next := u.Unwrap(); next != nil {
+				queue = append(queue, next)
+			}
+		case interface{ Unwrap() []error }:
+			queue = append(queue, u.Unwrap()...)
+		}
+	}
+	return chain
+}
+
+func errorType(err error) string {
+	return fmt.Sprintf("%T", err)
+}
+
+// errorStack returns the stack recorded closest to where err originated.
+func errorStack(err error) string {
+	var stack string
+	for _, e := range errorChain(err) {
+		if st, ok := e.(StackTracer); ok {
+			if s := st.StackTrace(); s != "" {
+				stack = s
+			}
+		}
+	}
+	return stack
+}
+
+// hasErrorStack reports whether one of fields carries an error stack trace.
+func hasErrorStack(fields []Field) bool {
+	for _, f := range fields {
+		var err error
+		switch v := f.Interface.(type) {
+		case errorObject:
+			err = v.err
+		case error:
+			if f.Type == zapcore.ErrorType {
+				err = v
+			}
+		}
+		if err != nil && errorStack(err) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupeStackCore drops the logger's stack trace from entries whose error
+// fields already carry the stack captured at wrap time.
+type dedupeStackCore struct {
+	zapcore.Core
+	withStack bool // an error with a stack was added via With
+}
+
+func newDedupeStackCore(core zapcore.Core) zapcore.Core {
+	return &dedupeStackCore{Core: core}
+}
+
+func (c *dedupeStackCore) With(fields []Field) zapcore.Core {
+	return &dedupeStackCore{Core: c.Core.With(fields), withStack: c.withStack || hasErrorStack(fields)}
+}
+
+func (c *dedupeStackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *dedupeStackCore) Write(ent zapcore.Entry, fields []Field) error {
+	if ent.Stack != "" && (c.withStack || hasErrorStack(fields)) {
+		ent.Stack = ""
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// ErrorE logs msg at error level with err recorded by Err.
+func (l *Logger) ErrorE(err error, msg string, fields ...Field) {
+	fields = append([]Field{Err(err)}, fields...)
+	l.executeHooks(ErrorLevel, msg, fields)
+	l.zl.Error(msg, fields...)
+}
+
+// WarnE logs msg at warn level with err recorded by Err.
+func (l *Logger) WarnE(err error, msg string, fields ...Field) {
+	fields = append([]Field{Err(err)}, fields...)
+	l.executeHooks(WarnLevel, msg, fields)
+	l.zl.Warn(msg, fields...)
+}
+
+// ErrorE logs msg at error level with err recorded by Err.
+func ErrorE(err error, msg string, fields ...Field) {
+	pkg().ErrorE(err, msg, fields...)
+}
+
+// WarnE logs msg at warn level with err recorded by Err.
+func WarnE(err error, msg string, fields ...Field) {
+	pkg().WarnE(err, msg, fields...)
+}
@@ -0,0 +1,179 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// firstFrame returns the function of the first frame of a stack trace.
+func firstFrame(stack string) string {
+	line, _, _ := strings.Cut(stack, "\n")
+	return line
+}
+
+func TestWrapCapturesStackOnce(t *testing.T) {
+	if Wrap(nil, "x") != nil || Wrapf(nil, "x %d", 1) != nil || WithStack(nil) != nil {
+		t.Fatal("nil error wrapped")
+	}
+
+	inner := Wrap(io.EOF, "read header")
+	if got := firstFrame(inner.(StackTracer).StackTrace()); got != "github.com/chenzanhong/goutil/zlog.TestWrapCapturesStackOnce" {
+		t.Errorf("stack starts at %q, want the caller of Wrap", got)
+	}
+
+	outer := Wrapf(fmt.Errorf("decode: %w", inner), "load %s", "config")
+	if outer.Error() != "load config: decode: read header: EOF" {
+		t.Errorf("Error() = %q", outer.Error())
+	}
+	if len(outer.(*stackError).stack) != 0 {
+		t.Error("Wrapf captured a second stack although the chain has one")
+	}
+	if outer.(StackTracer).StackTrace() != inner.(StackTracer).StackTrace() {
+		t.Error("StackTrace does not report the stack of the wrapped error")
+	}
+	if !errors.Is(outer, io.EOF) {
+		t.Error("wrapped error does not unwrap to the cause")
+	}
+	if WithStack(inner) != inner {
+		t.Error("WithStack replaced an error that has a stack")
+	}
+
+	plain := Wrapf(io.EOF, "read %d bytes", 4)
+	if len(plain.(*stackError).stack) == 0 {
+		t.Error("Wrapf did not capture a stack")
+	}
+
+	verbose := fmt.Sprintf("%+v", inner)
+	if !strings.HasPrefix(verbose, "read header: EOF\n") || !strings.Contains(verbose, "errors_test.go:") {
+		t.Errorf("%%+v = %q", verbose)
+	}
+	if got := fmt.Sprintf("%v|%s|%q", inner, inner, inner); got != `read header: EOF|read header: EOF|"read header: EOF"` {
+		t.Errorf("formatting = %s", got)
+	}
+}
+
+// encodeField returns the value f adds to an object.
+func encodeField(f Field) (interface{}, bool) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	v, ok := enc.Fields[f.Key]
+	return v, ok
+}
+
+func TestErrField(t *testing.T) {
+	if _, ok := encodeField(Err(nil)); ok {
+		t.Error("Err(nil) added a field")
+	}
+
+	v, _ := encodeField(Err(io.EOF))
+	m := v.(map[string]interface{})
+	if m["msg"] != "EOF" || m["type"] != "*errors.errorString" {
+		t.Errorf("plain error = %v", m)
+	}
+	if _, ok := m["chain"]; ok {
+		t.Error("single error has a chain")
+	}
+	if _, ok := m["stack"]; ok {
+		t.Error("error without a stack has one")
+	}
+
+	err := fmt.Errorf("save order: %w", Wrap(io.ErrShortWrite, "flush"))
+	v, ok := encodeField(NamedErr("cause", err))
+	if !ok {
+		t.Fatal("NamedErr did not use its key")
+	}
+	m = v.(map[string]interface{})
+	if m["msg"] != "save order: flush: short write" || m["type"] != "*fmt.wrapError" {
+		t.Errorf("wrapped error = %v", m)
+	}
+	chain := m["chain"].([]interface{})
+	wantTypes := []string{"*fmt.wrapError", "*zlog.stackError", "*errors.errorString"}
+	if len(chain) != len(wantTypes) {
+		t.Fatalf("chain = %v", chain)
+	}
+	for i, want := range wantTypes {
+		if got := chain[i].(map[string]interface{})["type"]; got != want {
+			t.Errorf("chain[%d].type = %v, want %s", i, got, want)
+		}
+	}
+	if stack, _ := m["stack"].(string); firstFrame(stack) != "github.com/chenzanhong/goutil/zlog.TestErrField" {
+		t.Errorf("stack = %q", stack)
+	}
+
+	v, _ = encodeField(Err(errors.Join(io.EOF, io.ErrClosedPipe)))
+	if chain := v.(map[string]interface{})["chain"].([]interface{}); len(chain) != 3 {
+		t.Errorf("joined chain = %v", chain)
+	}
+}
+
+func TestErrorEWarnE(t *testing.T) {
+	keepGlobals(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewWithCore(core)
+	ReplaceGlobals(l)
+
+	err := Wrap(io.EOF, "read")
+	l.ErrorE(err, "import failed", String("file", "a.csv"))
+	l.WarnE(err, "import retried")
+	ErrorE(err, "package error")
+	WarnE(nil, "package warn")
+
+	entries := logs.TakeAll()
+	want := []struct {
+		level zapcore.Level
+		msg   string
+	}{
+		{zapcore.ErrorLevel, "import failed"},
+		{zapcore.WarnLevel, "import retried"},
+		{zapcore.ErrorLevel, "package error"},
+		{zapcore.WarnLevel, "package warn"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	for i, e := range entries {
+		if e.Level != want[i].level || e.Message != want[i].msg {
+			t.Errorf("entry %d = %v %q", i, e.Level, e.Message)
+		}
+		if filepath.Base(e.Caller.File) != "errors_test.go" {
+			t.Errorf("entry %d caller = %s", i, e.Caller)
+		}
+		ctx := e.ContextMap()
+		if _, ok := ctx["error"]; ok == (i == 3) {
+			t.Errorf("entry %d fields = %v", i, ctx)
+		}
+	}
+	if entries[0].ContextMap()["file"] != "a.csv" {
+		t.Error("extra fields dropped")
+	}
+}
+
+func TestDedupeStacktrace(t *testing.T) {
+	for _, dedupe := range []bool{false, true} {
+		core, logs := observer.New(zapcore.DebugLevel)
+		l, err := NewWithCoreConfig(core, LoggerConfig{Level: DebugLevel, DedupeStacktrace: dedupe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrapped := Wrap(io.EOF, "read")
+
+		l.Error("plain", Err(io.EOF))
+		l.Error("wrapped", Err(wrapped))
+		l.ErrorE(wrapped, "error e")
+		l.With(Err(wrapped)).Error("with")
+
+		for _, e := range logs.TakeAll() {
+			wantStack := !dedupe || e.Message == "plain"
+			if hasStack := e.Stack != ""; hasStack != wantStack {
+				t.Errorf("dedupe=%v %q: logger stack present = %v", dedupe, e.Message, hasStack)
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package zlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FatalConfig controls what happens after an entry is logged at fatal level:
+// callbacks registered with OnFatal run, hooks and outputs are flushed, the
+// crash dump is written and the process exits with ExitCode.
+type FatalConfig struct {
+	ExitCode       int           `yaml:"exit_code"`        // default 1
+	Timeout        time.Duration `yaml:"timeout"`          // limit for callbacks and flushing, default 5s
+	CrashDump      string        `yaml:"crash_dump"`       // file receiving the latest entries as JSON lines; empty disables
+	CrashDumpSize  int           `yaml:"crash_dump_size"`  // entries kept for the dump, default 100
+	CrashDumpLevel Level         `yaml:"crash_dump_level"` // lowest level kept for the dump, default debug
+}
+
+// FlushHook is implemented by hooks that buffer entries; Flush is called
+// before the process exits on a fatal entry.
+type FlushHook interface {
+	LogHook
+	Flush() error
+}
+
+// fatalHandler is installed as zap's fatal hook for loggers built by New.
+type fatalHandler struct {
+	cfg   FatalConfig
+	parts *loggerParts
+	hooks *hookRegistry  // of the owning Logger
+	exit  func(code int) // os.Exit
+
+	mu        sync.Mutex
+	callbacks []func()
+	once      sync.Once
+}
+
+func newFatalHandler(cfg *FatalConfig, parts *loggerParts) *fatalHandler {
+	h := &fatalHandler{parts: parts, exit: os.Exit}
+	if cfg != nil {
+		h.cfg = *cfg
+	}
+	if h.cfg.ExitCode == 0 {
+		h.cfg.ExitCode = 1
+	}
+	if h.cfg.Timeout <= 0 {
+		h.cfg.Timeout = 5 * time.Second
+	}
+	if h.cfg.CrashDump != "" {
+		if h.cfg.CrashDumpSize <= 0 {
+			h.cfg.CrashDumpSize = 100
+		}
+		if !h.cfg.CrashDumpLevel.Valid() {
+			h.cfg.CrashDumpLevel = DebugLevel
+		}
+	}
+	return h
+}
+
+func (h *fatalHandler) onFatal(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.callbacks = append(h.callbacks, fn)
+}
+
+// OnWrite implements zapcore.CheckWriteHook. It never returns.
+func (h *fatalHandler) OnWrite(*zapcore.CheckedEntry, []Field) {
+	h.once.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.shutdown()
+		}()
+		select {
+		case <-done:
+		case <-time.After(h.cfg.Timeout):
+			fmt.Fprintf(os.Stderr, "[zlog] fatal: shutdown did not finish within %s\n", h.cfg.Timeout)
+		}
+	})
+	h.exit(h.cfg.ExitCode)
+}
+
+func (h *fatalHandler) shutdown() {
+	h.mu.Lock()
+	callbacks := append([]func(){}, h.callbacks...)
+	h.mu.Unlock()
+	// Latest registrations first, like deferred calls.
+	for i := len(callbacks) - 1; i >= 0; i-- {
+		runFatalCallback(callbacks[i])
+	}
+
+	hooks := globalHooks.snapshot()
+	if h.hooks != nil {
+		hooks = append(hooks, h.hooks.snapshot()...)
+	}
+	for _, hook := range hooks {
+		if f, ok := hook.(FlushHook); ok {
+			if err := f.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "[zlog] LogHook flush error: %v\n", err)
+			}
+		}
+	}
+
+	if h.cfg.CrashDump != "" && h.parts.recent != nil {
+		entries := filterRecent(h.parts.recent.snapshot(), RecentFilter{
+			Level: h.cfg.CrashDumpLevel,
+			Limit: h.cfg.CrashDumpSize,
+		})
+		if err := writeCrashDump(h.cfg.CrashDump, entries); err != nil {
+			fmt.Fprintf(os.Stderr, "[zlog] crash dump: %v\n", err)
+		}
+	}
+	_ = h.parts.logger.Sync()
+	h.parts.close()
+}
+
+func runFatalCallback(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "[zlog] OnFatal callback panicked: %v\n", r)
+		}
+	}()
+	fn()
+}
+
+// writeCrashDump writes entries to path as JSON lines, replacing the file.
+func writeCrashDump(path string, entries []*recentEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encodeRecent(f, entries, false); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// OnFatal registers fn to run when l or a logger derived from it logs at
+// fatal level, before outputs are flushed and the process exits. Callbacks
+// run in reverse order of registration; a panicking callback is skipped.
+// Loggers from NewWithCore exit through zap directly and ignore it.
+func (l *Logger) OnFatal(fn func()) {
+	if l.parts.fatal != nil {
+		l.parts.fatal.onFatal(fn)
+	}
+}
+
+// OnFatal registers fn on the default instance, see Logger.OnFatal.
+func OnFatal(fn func()) {
+	L().OnFatal(fn)
+}
+
+// Go runs fn in a new goroutine. A panic in fn is recovered and logged at
+// error level with the stack of the panic instead of crashing the process.
+func (l *Logger) Go(fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				l.logPanic(r)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Go runs fn in a new goroutine using the default instance, see Logger.Go.
+func Go(fn func()) {
+	L().Go(fn)
+}
+
+// Recover logs a panic in progress at error level and stops it. It must be
+// deferred directly:
+//
+//	defer logger.Recover()
+func (l *Logger) Recover() {
+	if r := recover(); r != nil {
+		l.logPanic(r)
+	}
+}
+
+// Recover is Logger.Recover on the default instance; use it as
+// defer zlog.Recover().
+func Recover() {
+	if r := recover(); r != nil {
+		L().logPanic(r)
+	}
+}
+
+// logPanic logs a recovered panic value. It runs inside the deferred
+// function, so the stack still holds the panicking frames; caller and stack
+// trace start at the frame that panicked.
+func (l *Logger) logPanic(r interface{}) {
+	field := zap.Any("panic", r)
+	if err, ok := r.(error); ok {
+		field = NamedErr("panic", err)
+	}
+	const msg = "recovered from panic"
+	l.executeHooks(ErrorLevel, msg, []Field{field})
+	l.base.WithOptions(zap.AddCallerSkip(panicSkip())).Error(msg, field)
+}
+
+// panicSkip returns the number of frames between logPanic and the function
+// that panicked, skipping the runtime frames in between.
+func panicSkip() int {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs) // skip Callers, panicSkip and logPanic
+	frames := runtime.CallersFrames(pcs[:n])
+	panicking := false
+	for skip := 1; ; skip++ {
+		f, more := frames.Next()
+		if f.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(f.Function, "runtime.") {
+			return skip
+		}
+		if !more {
+			return 1
+		}
+	}
+}
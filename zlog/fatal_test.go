@@ -0,0 +1,215 @@
+package zlog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fatalLogger returns a logger whose fatal handler reports the exit code on
+// the returned channel instead of exiting.
+func fatalLogger(t *testing.T, config LoggerConfig) (*Logger, *observer.ObservedLogs, <-chan int) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	if config.Level == "" {
+		config.Level = InfoLevel
+	}
+	l, err := NewWithCoreConfig(core, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan int, 1)
+	l.parts.fatal.exit = func(code int) { exited <- code }
+	return l, logs, exited
+}
+
+func waitExit(t *testing.T, exited <-chan int) int {
+	t.Helper()
+	select {
+	case code := <-exited:
+		return code
+	case <-time.After(2 * time.Second):
+		t.Fatal("fatal handler did not exit")
+		return 0
+	}
+}
+
+func TestFatalCallbacks(t *testing.T) {
+	l, logs, exited := fatalLogger(t, LoggerConfig{Fatal: &FatalConfig{ExitCode: 7}})
+
+	var mu sync.Mutex
+	var order []int
+	record := func(n int) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, n)
+		}
+	}
+	l.OnFatal(record(1))
+	l.OnFatal(func() { panic("callback failed") })
+	l.Named("db").OnFatal(record(3))
+
+	l.Fatal("cannot start")
+	if code := waitExit(t, exited); code != 7 {
+		t.Errorf("exit code = %d, want 7", code)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != 3 || order[1] != 1 {
+		t.Errorf("callbacks ran in order %v, want [3 1]", order)
+	}
+	if logs.FilterMessage("cannot start").Len() != 1 {
+		t.Error("fatal entry was not written before exiting")
+	}
+}
+
+func TestFatalTimeout(t *testing.T) {
+	l, _, exited := fatalLogger(t, LoggerConfig{Fatal: &FatalConfig{Timeout: 50 * time.Millisecond}})
+	release := make(chan struct{})
+	defer close(release)
+	l.OnFatal(func() { <-release })
+
+	start := time.Now()
+	l.Fatal("stuck")
+	if code := waitExit(t, exited); code != 1 {
+		t.Errorf("exit code = %d, want the default 1", code)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("exit took %s with a 50ms timeout", d)
+	}
+}
+
+func TestFatalCrashDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash", "dump.log")
+	l, _, exited := fatalLogger(t, LoggerConfig{
+		Fatal: &FatalConfig{CrashDump: path, CrashDumpSize: 3, CrashDumpLevel: InfoLevel},
+	})
+
+	l.Debug("too low")
+	l.Info("first")
+	l.With(String("request_id", "r1")).Info("second")
+	l.Warn("third")
+	l.Fatal("crash")
+	waitExit(t, exited)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{`"msg":"second"`, `"msg":"third"`, `"msg":"crash"`}
+	if len(lines) != len(want) {
+		t.Fatalf("dump has %d lines, want %d:\n%s", len(lines), len(want), data)
+	}
+	for i, w := range want {
+		if !strings.Contains(lines[i], w) {
+			t.Errorf("line %d = %s, want %s", i, lines[i], w)
+		}
+	}
+	if !strings.Contains(lines[0], `"request_id":"r1"`) {
+		t.Errorf("dump lost the With fields: %s", lines[0])
+	}
+	if !strings.Contains(lines[2], `"level":"fatal"`) {
+		t.Errorf("fatal entry dumped as %s", lines[2])
+	}
+}
+
+// lineHere returns the line it is called from.
+func lineHere() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+// panicValue panics with a value and stores the line it panics on.
+func panicValue(line *int) {
+	*line = lineHere() + 1
+	panic("boom")
+}
+
+// panicNil panics in the runtime, through runtime.sigpanic.
+func panicNil(line *int) {
+	var p *struct{ n int }
+	*line = lineHere() + 1
+	p.n++
+}
+
+func TestPanicCaller(t *testing.T) {
+	keepGlobals(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewWithCore(core)
+	ReplaceGlobals(l)
+
+	tests := []struct {
+		name string
+		run  func(line *int)
+	}{
+		{"Recover", func(line *int) {
+			defer l.Recover()
+			panicValue(line)
+		}},
+		{"Recover runtime error", func(line *int) {
+			defer l.Recover()
+			panicNil(line)
+		}},
+		{"Go", func(line *int) {
+			done := make(chan struct{})
+			l.Go(func() {
+				defer close(done)
+				panicValue(line)
+			})
+			<-done
+		}},
+		{"Go runtime error", func(line *int) {
+			done := make(chan struct{})
+			l.Go(func() {
+				defer close(done)
+				panicNil(line)
+			})
+			<-done
+		}},
+		{"package Recover", func(line *int) {
+			defer Recover()
+			panicNil(line)
+		}},
+		{"package Go", func(line *int) {
+			done := make(chan struct{})
+			Go(func() {
+				defer close(done)
+				panicValue(line)
+			})
+			<-done
+		}},
+	}
+	for _, tt := range tests {
+		before := logs.Len()
+		var line int
+		tt.run(&line)
+		var entries []observer.LoggedEntry
+		for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(time.Millisecond) {
+			if entries = logs.All(); len(entries) > before || time.Now().After(deadline) {
+				break
+			}
+		}
+		if len(entries) != before+1 {
+			t.Fatalf("%s: %d entries logged, want 1", tt.name, len(entries)-before)
+		}
+		e := entries[before]
+		if e.Level != zapcore.ErrorLevel || e.Message != "recovered from panic" {
+			t.Errorf("%s: logged %s %q", tt.name, e.Level, e.Message)
+		}
+		if !strings.HasSuffix(e.Caller.File, "fatal_test.go") || e.Caller.Line != line {
+			t.Errorf("%s: caller = %s:%d, want fatal_test.go:%d", tt.name, e.Caller.File, e.Caller.Line, line)
+		}
+		if !strings.HasPrefix(e.Stack, "github.com/chenzanhong/goutil/zlog.panic") {
+			t.Errorf("%s: stack starts at\n%s", tt.name, e.Stack)
+		}
+	}
+}
@@ -1,27 +1,33 @@
 package zlog
 
 import (
+	"fmt"
 	"time"
 
 	"go.uber.org/zap"
-)
-
-type fieldType int
-
-const (
-	fieldAny fieldType = iota
-	fieldString
-	fieldInt
-	fieldInt64
-	fieldBool
-	fieldFloat64
-	fieldDuration
-	fieldTime
+	"go.uber.org/zap/zapcore"
 )
 
 // Field is zlog's custom log field type, hiding zap.Field internally
 type Field = zap.Field
 
+// ObjectEncoder and ArrayEncoder receive the contents of LogObject and LogArray values.
+type (
+	ObjectEncoder = zapcore.ObjectEncoder
+	ArrayEncoder  = zapcore.ArrayEncoder
+)
+
+// LogObject is implemented by types that log themselves as an object,
+// avoiding reflection entirely.
+type LogObject interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// LogArray is implemented by types that log themselves as an array.
+type LogArray interface {
+	MarshalLogArray(enc ArrayEncoder) error
+}
+
 // Constructor functions
 func String(key, val string) Field                 { return zap.String(key, val) }
 func Int(key string, val int) Field                { return zap.Int(key, val) }
@@ -31,3 +37,57 @@ func Float64(key string, val float64) Field        { return zap.Float64(key, val
 func Duration(key string, val time.Duration) Field { return zap.Duration(key, val) }
 func Time(key string, val time.Time) Field         { return zap.Time(key, val) }
 func Any(key string, val interface{}) Field        { return zap.Any(key, val) }
+
+// Sized numbers and raw bytes
+func Int32(key string, val int32) Field       { return zap.Int32(key, val) }
+func Int16(key string, val int16) Field       { return zap.Int16(key, val) }
+func Int8(key string, val int8) Field         { return zap.Int8(key, val) }
+func Uint(key string, val uint) Field         { return zap.Uint(key, val) }
+func Uint64(key string, val uint64) Field     { return zap.Uint64(key, val) }
+func Uint32(key string, val uint32) Field     { return zap.Uint32(key, val) }
+func Uint16(key string, val uint16) Field     { return zap.Uint16(key, val) }
+func Uint8(key string, val uint8) Field       { return zap.Uint8(key, val) }
+func Float32(key string, val float32) Field   { return zap.Float32(key, val) }
+func ByteString(key string, val []byte) Field { return zap.ByteString(key, val) } // UTF-8 text held in bytes
+func Binary(key string, val []byte) Field     { return zap.Binary(key, val) }     // opaque bytes, base64 in JSON
+
+// Slices
+func Strings(key string, val []string) Field          { return zap.Strings(key, val) }
+func Ints(key string, val []int) Field                { return zap.Ints(key, val) }
+func Int64s(key string, val []int64) Field            { return zap.Int64s(key, val) }
+func Uint64s(key string, val []uint64) Field          { return zap.Uint64s(key, val) }
+func Float64s(key string, val []float64) Field        { return zap.Float64s(key, val) }
+func Bools(key string, val []bool) Field              { return zap.Bools(key, val) }
+func Durations(key string, val []time.Duration) Field { return zap.Durations(key, val) }
+
+// DurationMs logs d as a number of milliseconds (with fractions), independent
+// of the encoder's duration format.
+func DurationMs(key string, d time.Duration) Field {
+	return zap.Float64(key, float64(d)/float64(time.Millisecond))
+}
+
+// Stringer logs val.String(), evaluated only when the entry is written.
+func Stringer(key string, val fmt.Stringer) Field { return zap.Stringer(key, val) }
+
+// Object logs val as a nested object.
+func Object(key string, val LogObject) Field { return zap.Object(key, val) }
+
+// Array logs val as an array.
+func Array(key string, val LogArray) Field { return zap.Array(key, val) }
+
+// Namespace nests all fields added after it, including those added later by
+// With, under key.
+func Namespace(key string) Field { return zap.Namespace(key) }
+
+// Group logs fields as one nested object under key.
+func Group(key string, fields ...Field) Field {
+	return zap.Object(key, zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+		return nil
+	}))
+}
+
+// Skip is a no-op field, useful for optional fields.
+func Skip() Field { return zap.Skip() }
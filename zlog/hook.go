@@ -6,27 +6,76 @@ import (
 	"sync"
 )
 
-var (
-	globalHooks []LogHook
-	hooksMutex  sync.RWMutex
-)
-
 type LogHook interface {
 	OnLog(level Level, msg string, fields []Field) error
 }
 
+// hookRegistry is a concurrency-safe list of hooks.
+type hookRegistry struct {
+	mu    sync.RWMutex
+	hooks []LogHook
+}
+
+func (r *hookRegistry) add(hook LogHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+func (r *hookRegistry) snapshot() []LogHook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hooks := make([]LogHook, len(r.hooks))
+	copy(hooks, r.hooks)
+	return hooks
+}
+
+// globalHooks run for every entry logged through the default instance: the
+// package-level functions and L, Named, the Gin middleware and the loggers
+// derived from them, whichever instance is installed as the default.
+var globalHooks hookRegistry
+
+// RegisterLogHook registers a hook for the default instance, see globalHooks.
 func RegisterLogHook(hook LogHook) {
-	hooksMutex.Lock()
-	defer hooksMutex.Unlock()
-	globalHooks = append(globalHooks, hook)
+	globalHooks.add(hook)
+}
+
+// RegisterHook registers a hook on l and the loggers derived from it.
+func (l *Logger) RegisterHook(hook LogHook) {
+	l.hooks.add(hook)
 }
 
-// executeHooks is called within logWithFields
-func executeHooks(zlogLevel Level, msg string, fields []Field) {
-	hooksMutex.RLock()
-	hooks := make([]LogHook, len(globalHooks))
-	copy(hooks, globalHooks)
-	hooksMutex.RUnlock()
+func (l *Logger) activeHooks() []LogHook {
+	hooks := l.hooks.snapshot()
+	if l.isDefault() {
+		hooks = append(globalHooks.snapshot(), hooks...)
+	}
+	return hooks
+}
+
+// executeHooks is called by every logging method before the entry is written.
+func (l *Logger) executeHooks(zlogLevel Level, msg string, fields []Field) {
+	hooks := l.activeHooks()
+	if len(hooks) == 0 {
+		return
+	}
+	l.runHooks(hooks, zlogLevel, msg, fields)
+}
+
+// executeHooksf formats the message only when there is a hook to receive it.
+func (l *Logger) executeHooksf(zlogLevel Level, format string, args []interface{}) {
+	hooks := l.activeHooks()
+	if len(hooks) == 0 {
+		return
+	}
+	l.runHooks(hooks, zlogLevel, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) runHooks(hooks []LogHook, zlogLevel Level, msg string, fields []Field) {
+	if l.parts.redactor != nil {
+		msg = l.parts.redactor.RedactString(msg)
+		fields = l.parts.redactor.RedactFields(fields)
+	}
 
 	for _, hook := range hooks {
 		if err := hook.OnLog(zlogLevel, msg, fields); err != nil {
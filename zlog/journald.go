@@ -0,0 +1,184 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// journaldSocket is the native protocol socket of systemd-journald.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// newJournaldOutput builds a core that sends entries to systemd-journald
+// using its native protocol, so every field becomes a journal field
+// (request_id → REQUEST_ID) that can be queried with journalctl.
+func newJournaldOutput(o OutputConfig, enabler zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+	facility, err := parseSyslogFacility(o.Facility)
+	if err != nil {
+		return nil, nil, err
+	}
+	address := o.Address
+	if address == "" {
+		address = journaldSocket
+	}
+	appName := o.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+	w := newNetWriter(OutputUnixgram, address)
+	core := &journaldCore{
+		LevelEnabler: enabler,
+		out:          w,
+		identifier:   appName,
+		facility:     facility,
+	}
+	return core, w, nil
+}
+
+type journaldCore struct {
+	zapcore.LevelEnabler
+	out        *netWriter
+	identifier string
+	facility   int
+	fields     []Field // accumulated by With
+}
+
+func (c *journaldCore) With(fields []Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	return &clone
+}
+
+func (c *journaldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *journaldCore) Write(ent zapcore.Entry, fields []Field) error {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", ent.Message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(fromZapCoreLevel(ent.Level).SyslogSeverity()))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", c.identifier)
+	writeJournalField(&buf, "SYSLOG_FACILITY", strconv.Itoa(c.facility))
+	if ent.LoggerName != "" {
+		writeJournalField(&buf, "LOGGER", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		writeJournalField(&buf, "CODE_FILE", ent.Caller.File)
+		writeJournalField(&buf, "CODE_LINE", strconv.Itoa(ent.Caller.Line))
+		writeJournalField(&buf, "CODE_FUNC", ent.Caller.Function)
+	}
+	if ent.Stack != "" {
+		writeJournalField(&buf, "STACKTRACE", ent.Stack)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := journalFieldName(k)
+		if journalReserved[name] {
+			name = "FIELD_" + name
+		}
+		writeJournalField(&buf, name, journalFieldValue(enc.Fields[k]))
+	}
+
+	_, err := c.out.Write(buf.Bytes())
+	if errors.Is(err, syscall.EMSGSIZE) {
+		// Too large for one datagram: pass the entry in a sealed memfd.
+		err = c.writeLarge(buf.Bytes())
+	}
+	return err
+}
+
+func (c *journaldCore) Sync() error {
+	return c.out.Sync()
+}
+
+// writeJournalField appends one field in the journal native format. Values
+// containing newlines use the binary form with a little-endian length.
+func writeJournalField(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalReserved are journal fields zlog writes itself or that journald
+// interprets. User fields with these names get a FIELD_ prefix, so a
+// "message" field cannot add a second MESSAGE to the entry.
+var journalReserved = map[string]bool{
+	"MESSAGE": true, "MESSAGE_ID": true, "PRIORITY": true, "ERRNO": true,
+	"CODE_FILE": true, "CODE_LINE": true, "CODE_FUNC": true,
+	"SYSLOG_IDENTIFIER": true, "SYSLOG_FACILITY": true, "SYSLOG_PID": true,
+	"SYSLOG_TIMESTAMP": true, "SYSLOG_RAW": true, "DOCUMENTATION": true,
+	"INVOCATION_ID": true, "USER_INVOCATION_ID": true, "TID": true,
+	"UNIT": true, "USER_UNIT": true, "LOGGER": true, "STACKTRACE": true,
+}
+
+// journalFieldName converts key to a valid journal field name: upper case
+// letters, digits and underscores, not starting with an underscore or digit.
+func journalFieldName(key string) string {
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key) && len(b) < 64; i++ {
+		ch := key[i]
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			b = append(b, ch-'a'+'A')
+		case ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+			b = append(b, ch)
+		default:
+			b = append(b, '_')
+		}
+	}
+	name := strings.TrimLeft(string(b), "_0123456789")
+	if name == "" {
+		return "FIELD"
+	}
+	return name
+}
+
+func journalFieldValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
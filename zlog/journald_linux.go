@@ -0,0 +1,76 @@
+package zlog
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeLarge sends an entry too large for a datagram the way sd_journal_send
+// does: the payload goes into a sealed memfd, or an unlinked file on
+// /dev/shm without memfd support, whose descriptor is passed to journald.
+func (c *journaldCore) writeLarge(p []byte) error {
+	f, err := journalFile(p)
+	if err != nil {
+		return fmt.Errorf("journald entry of %d bytes: %w", len(p), err)
+	}
+	defer f.Close()
+	return c.out.writeMsg(nil, syscall.UnixRights(int(f.Fd())))
+}
+
+// writeMsg sends p with the ancillary data oob over a unix socket
+// connection. net.UnixConn refuses WriteMsgUnix on connected datagram
+// sockets, so sendmsg is called on the raw connection.
+func (w *netWriter) writeMsg(p, oob []byte) error {
+	_, err := w.send(len(p), func(conn net.Conn) (int, error) {
+		sc, ok := conn.(syscall.Conn)
+		if !ok {
+			return 0, fmt.Errorf("%s %s is not a unix socket", w.network, w.addr)
+		}
+		rc, err := sc.SyscallConn()
+		if err != nil {
+			return 0, err
+		}
+		var sendErr error
+		err = rc.Write(func(fd uintptr) bool {
+			sendErr = syscall.Sendmsg(int(fd), p, oob, nil, 0)
+			return sendErr != syscall.EAGAIN
+		})
+		if err == nil {
+			err = sendErr
+		}
+		return len(p), err
+	})
+	return err
+}
+
+func journalFile(p []byte) (*os.File, error) {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err == nil {
+		f := os.NewFile(uintptr(fd), "journal-entry")
+		if _, err := f.Write(p); err != nil {
+			f.Close()
+			return nil, err
+		}
+		seals := unix.F_SEAL_SHRINK | unix.F_SEAL_GROW | unix.F_SEAL_WRITE | unix.F_SEAL_SEAL
+		if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, seals); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+
+	f, err := os.CreateTemp("/dev/shm", "journal.")
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(f.Name())
+	if _, err := f.Write(p); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package zlog
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// writeLarge reports entries too large for a datagram; passing them in a
+// memfd needs Linux, where journald runs.
+func (c *journaldCore) writeLarge(p []byte) error {
+	return fmt.Errorf("journald entry of %d bytes: %w", len(p), syscall.EMSGSIZE)
+}
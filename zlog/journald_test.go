@@ -0,0 +1,111 @@
+//go:build linux
+
+package zlog
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// journalListener stands in for journald's native socket and returns the
+// entries it receives, reading those passed as a file descriptor.
+func journalListener(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	entries := make(chan []byte, 10)
+	go func() {
+		buf := make([]byte, 1<<20)
+		oob := make([]byte, syscall.CmsgSpace(4))
+		for {
+			n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+			if err != nil {
+				return
+			}
+			if oobn == 0 {
+				entries <- append([]byte(nil), buf[:n]...)
+				continue
+			}
+			msgs, _ := syscall.ParseSocketControlMessage(oob[:oobn])
+			fds, _ := syscall.ParseUnixRights(&msgs[0])
+			f := os.NewFile(uintptr(fds[0]), "entry")
+			var data bytes.Buffer
+			_, _ = f.Seek(0, 0)
+			_, _ = data.ReadFrom(f)
+			f.Close()
+			entries <- data.Bytes()
+		}
+	}()
+	return path, entries
+}
+
+func receiveEntry(t *testing.T, entries <-chan []byte) string {
+	t.Helper()
+	select {
+	case e := <-entries:
+		return string(e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no journal entry received")
+		return ""
+	}
+}
+
+func TestJournaldReservedFields(t *testing.T) {
+	path, entries := journalListener(t)
+	l, err := New(LoggerConfig{Level: InfoLevel, Outputs: []OutputConfig{{Type: OutputJournald, Address: path, AppName: "shop"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Warn("order failed", zap.String("message", "user text"), zap.Int("priority", 1), zap.String("request_id", "r1"))
+	entry := receiveEntry(t, entries)
+
+	for _, want := range []string{"MESSAGE=order failed\n", "PRIORITY=4\n", "SYSLOG_IDENTIFIER=shop\n",
+		"FIELD_MESSAGE=user text\n", "FIELD_PRIORITY=1\n", "REQUEST_ID=r1\n"} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("entry lacks %q:\n%s", want, entry)
+		}
+	}
+	if n := strings.Count("\n"+entry, "\nMESSAGE="); n != 1 {
+		t.Errorf("entry has %d MESSAGE fields", n)
+	}
+	if n := strings.Count("\n"+entry, "\nPRIORITY="); n != 1 {
+		t.Errorf("entry has %d PRIORITY fields", n)
+	}
+}
+
+func TestJournaldLargeEntry(t *testing.T) {
+	path, entries := journalListener(t)
+	l, err := New(LoggerConfig{Level: InfoLevel, Outputs: []OutputConfig{{Type: OutputJournald, Address: path}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	big := strings.Repeat("x", 4<<20) // larger than any datagram the socket accepts
+	l.Info("large", zap.String("payload", big))
+	entry := receiveEntry(t, entries)
+	if !strings.Contains(entry, "MESSAGE=large\n") || !strings.Contains(entry, "PAYLOAD="+big+"\n") {
+		t.Errorf("large entry not received intact (%d bytes)", len(entry))
+	}
+
+	// The connection stays usable for ordinary entries.
+	l.Info("small")
+	if entry := receiveEntry(t, entries); !strings.Contains(entry, "MESSAGE=small\n") {
+		t.Errorf("unexpected entry after large one: %.200s", entry)
+	}
+}
@@ -0,0 +1,194 @@
+package zlog
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var layoutPool = buffer.NewPool()
+
+// layoutPlaceholders maps the placeholders of a layout template, including
+// aliases, to the entry part they render.
+var layoutPlaceholders = map[string]string{
+	"time": "time", "ts": "time",
+	"level":  "level",
+	"logger": "logger", "name": "logger",
+	"caller":   "caller",
+	"function": "function",
+	"msg":      "msg", "message": "msg",
+	"fields":     "fields",
+	"stacktrace": "stacktrace", "stack": "stacktrace",
+}
+
+type layoutPart struct {
+	literal string
+	field   string // entry part, empty for literals
+}
+
+// layoutEncoder renders entries through a line template.
+type layoutEncoder struct {
+	zapcore.Encoder // JSON encoder for the fields, holding the context added by With
+	cfg             *zapcore.EncoderConfig
+	parts           []layoutPart
+	hasStack        bool
+}
+
+// NewLayoutEncoder returns an encoder writing each entry as layout with the
+// placeholders {time}, {level}, {logger}, {caller}, {function}, {msg},
+// {fields} and {stacktrace} replaced, e.g.
+//
+//	{time} [{level}] {caller} {msg} {fields}
+//
+// Time, level and caller use the encoders in cfg. Fields are written as a
+// JSON object like the console encoder does. A placeholder with nothing to
+// show also drops the spaces after it, and a stack trace without a
+// {stacktrace} placeholder follows on the next lines.
+func NewLayoutEncoder(layout string, cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	parts, err := parseLayout(layout)
+	if err != nil {
+		return nil, err
+	}
+	jsonCfg := zapcore.EncoderConfig{
+		EncodeTime:     cfg.EncodeTime,
+		EncodeDuration: cfg.EncodeDuration,
+		LineEnding:     "\n",
+	}
+	e := &layoutEncoder{
+		Encoder: zapcore.NewJSONEncoder(jsonCfg),
+		cfg:     &cfg,
+		parts:   parts,
+	}
+	for _, p := range parts {
+		if p.field == "stacktrace" {
+			e.hasStack = true
+		}
+	}
+	return e, nil
+}
+
+func parseLayout(layout string) ([]layoutPart, error) {
+	var parts []layoutPart
+	for s := layout; s != ""; {
+		i := strings.IndexByte(s, '{')
+		if i < 0 {
+			parts = append(parts, layoutPart{literal: s})
+			break
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in layout %q", layout)
+		}
+		name := s[i+1 : i+j]
+		field, ok := layoutPlaceholders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder {%s} in layout %q", name, layout)
+		}
+		if i > 0 {
+			parts = append(parts, layoutPart{literal: s[:i]})
+		}
+		parts = append(parts, layoutPart{field: field})
+		s = s[i+j+1:]
+	}
+	return parts, nil
+}
+
+func (e *layoutEncoder) Clone() zapcore.Encoder {
+	c := *e
+	c.Encoder = e.Encoder.Clone()
+	return &c
+}
+
+func (e *layoutEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	line := layoutPool.Get()
+	skipSpace := false
+	for _, p := range e.parts {
+		if p.field == "" {
+			lit := p.literal
+			if skipSpace {
+				lit = strings.TrimLeft(lit, " \t")
+			}
+			line.AppendString(lit)
+			skipSpace = false
+			continue
+		}
+		before := line.Len()
+		if err := e.appendPart(line, p.field, ent, fields); err != nil {
+			line.Free()
+			return nil, err
+		}
+		skipSpace = line.Len() == before
+	}
+
+	out := layoutPool.Get()
+	out.Write(bytes.TrimRight(line.Bytes(), " \t"))
+	line.Free()
+	if ent.Stack != "" && !e.hasStack && e.cfg.StacktraceKey != "" {
+		out.AppendByte('\n')
+		out.AppendString(ent.Stack)
+	}
+	lineEnding := e.cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	out.AppendString(lineEnding)
+	return out, nil
+}
+
+func (e *layoutEncoder) appendPart(line *buffer.Buffer, field string, ent zapcore.Entry, fields []zapcore.Field) error {
+	switch field {
+	case "time":
+		if !ent.Time.IsZero() && e.cfg.EncodeTime != nil {
+			appendPrimitive(line, func(enc zapcore.PrimitiveArrayEncoder) { e.cfg.EncodeTime(ent.Time, enc) })
+		}
+	case "level":
+		encode := e.cfg.EncodeLevel
+		if encode == nil {
+			encode = zapcore.CapitalLevelEncoder
+		}
+		appendPrimitive(line, func(enc zapcore.PrimitiveArrayEncoder) { encode(ent.Level, enc) })
+	case "logger":
+		line.AppendString(ent.LoggerName)
+	case "caller":
+		if ent.Caller.Defined {
+			encode := e.cfg.EncodeCaller
+			if encode == nil {
+				encode = zapcore.ShortCallerEncoder
+			}
+			appendPrimitive(line, func(enc zapcore.PrimitiveArrayEncoder) { encode(ent.Caller, enc) })
+		}
+	case "function":
+		if ent.Caller.Defined {
+			line.AppendString(ent.Caller.Function)
+		}
+	case "msg":
+		line.AppendString(ent.Message)
+	case "fields":
+		buf, err := e.Encoder.EncodeEntry(zapcore.Entry{}, fields)
+		if err != nil {
+			return err
+		}
+		if obj := bytes.TrimRight(buf.Bytes(), "\n"); len(obj) > 2 {
+			line.Write(obj)
+		}
+		buf.Free()
+	case "stacktrace":
+		line.AppendString(ent.Stack)
+	}
+	return nil
+}
+
+// appendPrimitive writes the value produced by an EncoderConfig callback.
+func appendPrimitive(line *buffer.Buffer, encode func(zapcore.PrimitiveArrayEncoder)) {
+	var vals primitiveCapture
+	encode(&vals)
+	for i, v := range vals {
+		if i > 0 {
+			line.AppendByte(' ')
+		}
+		line.AppendString(primitiveString(v))
+	}
+}
@@ -34,6 +34,33 @@ func (l Level) Valid() bool {
 	}
 }
 
+// Severity returns the numeric rank of l, from 0 for debug to 5 for fatal,
+// so levels can be compared: l.Severity() >= WarnLevel.Severity(). Unknown
+// levels return -1.
+func (l Level) Severity() int {
+	switch l {
+	case DebugLevel:
+		return 0
+	case InfoLevel:
+		return 1
+	case WarnLevel:
+		return 2
+	case ErrorLevel:
+		return 3
+	case PanicLevel:
+		return 4
+	case FatalLevel:
+		return 5
+	default:
+		return -1
+	}
+}
+
+// Enabled reports whether a logger at level l writes entries at lvl.
+func (l Level) Enabled(lvl Level) bool {
+	return lvl.Valid() && lvl.Severity() >= l.Severity()
+}
+
 // UnmarshalText implements encoding.TextUnmarshaler
 // Supports parsing from YAML, JSON, TOML, env vars, etc.
 func (l *Level) UnmarshalText(text []byte) error {
@@ -0,0 +1,333 @@
+package zlog
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder encodes entries as logfmt lines: key=value pairs separated by
+// spaces, with values quoted when needed. Nested objects and arrays are written
+// as quoted JSON; namespaces prefix the keys that follow them ("ns.key").
+type logfmtEncoder struct {
+	cfg       *zapcore.EncoderConfig
+	buf       *buffer.Buffer
+	namespace string
+}
+
+// NewLogfmtEncoder returns a zapcore.Encoder producing logfmt output.
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &logfmtEncoder{cfg: &cfg, buf: logfmtPool.Get()}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	c := &logfmtEncoder{cfg: e.cfg, buf: logfmtPool.Get(), namespace: e.namespace}
+	c.buf.Write(e.buf.Bytes())
+	return c
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	final := &logfmtEncoder{cfg: e.cfg, buf: logfmtPool.Get()}
+
+	if e.cfg.TimeKey != "" && !ent.Time.IsZero() {
+		final.addEncoded(e.cfg.TimeKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if e.cfg.EncodeTime != nil {
+				e.cfg.EncodeTime(ent.Time, enc)
+			} else {
+				enc.AppendString(ent.Time.Format(time.RFC3339Nano))
+			}
+		})
+	}
+	if e.cfg.LevelKey != "" {
+		final.addEncoded(e.cfg.LevelKey, func(enc zapcore.PrimitiveArrayEncoder) {
+			if e.cfg.EncodeLevel != nil {
+				e.cfg.EncodeLevel(ent.Level, enc)
+			} else {
+				enc.AppendString(ent.Level.String())
+			}
+		})
+	}
+	if e.cfg.NameKey != "" && ent.LoggerName != "" {
+		final.AddString(e.cfg.NameKey, ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		if e.cfg.CallerKey != "" {
+			final.addEncoded(e.cfg.CallerKey, func(enc zapcore.PrimitiveArrayEncoder) {
+				if e.cfg.EncodeCaller != nil {
+					e.cfg.EncodeCaller(ent.Caller, enc)
+				} else {
+					enc.AppendString(ent.Caller.TrimmedPath())
+				}
+			})
+		}
+		if e.cfg.FunctionKey != "" {
+			final.AddString(e.cfg.FunctionKey, ent.Caller.Function)
+		}
+	}
+	if e.cfg.MessageKey != "" {
+		final.AddString(e.cfg.MessageKey, ent.Message)
+	}
+
+	// Context accumulated by With.
+	if e.buf.Len() > 0 {
+		final.sep()
+		final.buf.Write(e.buf.Bytes())
+	}
+	final.namespace = e.namespace
+	for _, f := range fields {
+		f.AddTo(final)
+	}
+	final.namespace = ""
+
+	if e.cfg.StacktraceKey != "" && ent.Stack != "" {
+		final.AddString(e.cfg.StacktraceKey, ent.Stack)
+	}
+
+	lineEnding := e.cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	final.buf.AppendString(lineEnding)
+	return final.buf, nil
+}
+
+// addEncoded writes the value produced by one of the EncoderConfig callbacks.
+func (e *logfmtEncoder) addEncoded(key string, encode func(zapcore.PrimitiveArrayEncoder)) {
+	var vals primitiveCapture
+	encode(&vals)
+	if len(vals) == 0 {
+		return
+	}
+	e.addKey(key)
+	e.appendValue(primitiveString(vals[0]))
+}
+
+func (e *logfmtEncoder) sep() {
+	if e.buf.Len() > 0 {
+		e.buf.AppendByte(' ')
+	}
+}
+
+func (e *logfmtEncoder) addKey(key string) {
+	e.sep()
+	if e.namespace != "" {
+		writeLogfmtKey(e.buf, e.namespace)
+		e.buf.AppendByte('.')
+	}
+	writeLogfmtKey(e.buf, key)
+	e.buf.AppendByte('=')
+}
+
+func writeLogfmtKey(buf *buffer.Buffer, key string) {
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError {
+			buf.AppendByte('_')
+			continue
+		}
+		buf.AppendString(string(r))
+	}
+}
+
+func (e *logfmtEncoder) appendValue(s string) {
+	if needsLogfmtQuote(s) {
+		e.buf.AppendString(strconv.Quote(s))
+		return
+	}
+	e.buf.AppendString(s)
+}
+
+func needsLogfmtQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *logfmtEncoder) addJSON(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	e.addKey(key)
+	e.appendValue(string(b))
+	return nil
+}
+
+// ObjectEncoder implementation.
+
+func (e *logfmtEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := enc.AddArray(key, arr); err != nil {
+		return err
+	}
+	return e.addJSON(key, enc.Fields[key])
+}
+
+func (e *logfmtEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	enc := zapcore.NewMapObjectEncoder()
+	if err := obj.MarshalLogObject(enc); err != nil {
+		return err
+	}
+	return e.addJSON(key, enc.Fields)
+}
+
+func (e *logfmtEncoder) AddBinary(key string, val []byte) {
+	e.AddString(key, base64.StdEncoding.EncodeToString(val))
+}
+
+func (e *logfmtEncoder) AddByteString(key string, val []byte) {
+	e.AddString(key, string(val))
+}
+
+func (e *logfmtEncoder) AddBool(key string, val bool) {
+	e.addKey(key)
+	e.buf.AppendBool(val)
+}
+
+func (e *logfmtEncoder) AddComplex128(key string, val complex128) {
+	e.addKey(key)
+	e.appendValue(strconv.FormatComplex(val, 'g', -1, 128))
+}
+
+func (e *logfmtEncoder) AddComplex64(key string, val complex64) {
+	e.addKey(key)
+	e.appendValue(strconv.FormatComplex(complex128(val), 'g', -1, 64))
+}
+
+func (e *logfmtEncoder) AddDuration(key string, val time.Duration) {
+	e.addEncoded(key, func(enc zapcore.PrimitiveArrayEncoder) {
+		if e.cfg.EncodeDuration != nil {
+			e.cfg.EncodeDuration(val, enc)
+		} else {
+			enc.AppendString(val.String())
+		}
+	})
+}
+
+func (e *logfmtEncoder) AddFloat64(key string, val float64) {
+	e.addKey(key)
+	switch {
+	case math.IsNaN(val):
+		e.buf.AppendString("NaN")
+	case math.IsInf(val, 1):
+		e.buf.AppendString("+Inf")
+	case math.IsInf(val, -1):
+		e.buf.AppendString("-Inf")
+	default:
+		e.buf.AppendFloat(val, 64)
+	}
+}
+
+func (e *logfmtEncoder) AddFloat32(key string, val float32) { e.AddFloat64(key, float64(val)) }
+
+func (e *logfmtEncoder) AddInt(key string, val int)     { e.AddInt64(key, int64(val)) }
+func (e *logfmtEncoder) AddInt32(key string, val int32) { e.AddInt64(key, int64(val)) }
+func (e *logfmtEncoder) AddInt16(key string, val int16) { e.AddInt64(key, int64(val)) }
+func (e *logfmtEncoder) AddInt8(key string, val int8)   { e.AddInt64(key, int64(val)) }
+
+func (e *logfmtEncoder) AddInt64(key string, val int64) {
+	e.addKey(key)
+	e.buf.AppendInt(val)
+}
+
+func (e *logfmtEncoder) AddString(key, val string) {
+	e.addKey(key)
+	e.appendValue(val)
+}
+
+func (e *logfmtEncoder) AddTime(key string, val time.Time) {
+	e.addEncoded(key, func(enc zapcore.PrimitiveArrayEncoder) {
+		if e.cfg.EncodeTime != nil {
+			e.cfg.EncodeTime(val, enc)
+		} else {
+			enc.AppendString(val.Format(time.RFC3339Nano))
+		}
+	})
+}
+
+func (e *logfmtEncoder) AddUint(key string, val uint)       { e.AddUint64(key, uint64(val)) }
+func (e *logfmtEncoder) AddUint32(key string, val uint32)   { e.AddUint64(key, uint64(val)) }
+func (e *logfmtEncoder) AddUint16(key string, val uint16)   { e.AddUint64(key, uint64(val)) }
+func (e *logfmtEncoder) AddUint8(key string, val uint8)     { e.AddUint64(key, uint64(val)) }
+func (e *logfmtEncoder) AddUintptr(key string, val uintptr) { e.AddUint64(key, uint64(val)) }
+
+func (e *logfmtEncoder) AddUint64(key string, val uint64) {
+	e.addKey(key)
+	e.buf.AppendUint(val)
+}
+
+func (e *logfmtEncoder) AddReflected(key string, val interface{}) error {
+	switch v := val.(type) {
+	case nil:
+		e.addKey(key)
+		e.buf.AppendString("null")
+		return nil
+	case string:
+		e.AddString(key, v)
+		return nil
+	case fmt.Stringer:
+		e.AddString(key, v.String())
+		return nil
+	}
+	return e.addJSON(key, val)
+}
+
+func (e *logfmtEncoder) OpenNamespace(key string) {
+	if e.namespace == "" {
+		e.namespace = key
+		return
+	}
+	e.namespace += "." + key
+}
+
+// primitiveString formats a captured value; floats never use exponents so
+// epoch timestamps stay readable.
+func primitiveString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return fmt.Sprint(v)
+}
+
+// primitiveCapture collects the values appended by EncoderConfig callbacks.
+type primitiveCapture []interface{}
+
+func (p *primitiveCapture) AppendBool(v bool)              { *p = append(*p, v) }
+func (p *primitiveCapture) AppendByteString(v []byte)      { *p = append(*p, string(v)) }
+func (p *primitiveCapture) AppendComplex128(v complex128)  { *p = append(*p, v) }
+func (p *primitiveCapture) AppendComplex64(v complex64)    { *p = append(*p, v) }
+func (p *primitiveCapture) AppendFloat64(v float64)        { *p = append(*p, v) }
+func (p *primitiveCapture) AppendFloat32(v float32)        { *p = append(*p, v) }
+func (p *primitiveCapture) AppendInt(v int)                { *p = append(*p, v) }
+func (p *primitiveCapture) AppendInt64(v int64)            { *p = append(*p, v) }
+func (p *primitiveCapture) AppendInt32(v int32)            { *p = append(*p, v) }
+func (p *primitiveCapture) AppendInt16(v int16)            { *p = append(*p, v) }
+func (p *primitiveCapture) AppendInt8(v int8)              { *p = append(*p, v) }
+func (p *primitiveCapture) AppendString(v string)          { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUint(v uint)              { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUint64(v uint64)          { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUint32(v uint32)          { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUint16(v uint16)          { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUint8(v uint8)            { *p = append(*p, v) }
+func (p *primitiveCapture) AppendUintptr(v uintptr)        { *p = append(*p, v) }
+func (p *primitiveCapture) AppendDuration(v time.Duration) { *p = append(*p, v.String()) }
+func (p *primitiveCapture) AppendTime(v time.Time)         { *p = append(*p, v.Format(time.RFC3339Nano)) }
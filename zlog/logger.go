@@ -2,191 +2,400 @@ package zlog
 
 import (
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// Global instances (for backward compatibility)
-var (
-	globalLogger        *zap.Logger
-	globalSugaredLogger *zap.SugaredLogger
-	once                sync.Once
-)
+// Logger is a logger instance built from a LoggerConfig. Hooks, redaction and
+// asynchronous outputs are scoped to the instance and shared by loggers derived
+// from it via With and Named. The package-level functions delegate to a
+// replaceable default instance, see ReplaceGlobals.
+type Logger struct {
+	base  *zap.Logger // no extra caller skip, returned by Zap
+	zl    *zap.Logger // skips the Logger method frame
+	sugar *zap.SugaredLogger
 
-// newLogger creates a new zap.Logger instance with automatic config validation,
-// default value filling, and path resolution.
-// internal helper, not exported
-func newLogger(config LoggerConfig) (*zap.Logger, error) {
-	cfg := config
+	hooks      *hookRegistry
+	spanEvents *atomic.Bool
+	parts      *loggerParts // outputs, shared with derived loggers
+
+	global bool // package-level wrapper: skips one more caller frame
+}
 
-	// Normalize log level
-	if cfg.Level < DebugLevel || cfg.Level > FatalLevel {
-		cfg.Level = InfoLevel
+// New builds a Logger from config.
+func New(config LoggerConfig) (*Logger, error) {
+	return newFromConfig(config, nil)
+}
+
+// NewWithCoreConfig builds a Logger from config that writes to core instead
+// of the configured outputs; core decides which levels are enabled. Redaction,
+// sampling, fields and span events from config still apply, which makes it
+// suitable for capturing entries in tests (see package zlogtest).
+func NewWithCoreConfig(core zapcore.Core, config LoggerConfig) (*Logger, error) {
+	return newFromConfig(config, core)
+}
+
+func newFromConfig(config LoggerConfig, core zapcore.Core) (*Logger, error) {
+	parts, err := newLogger(config, core)
+	if err != nil {
+		return nil, err
 	}
+	l := newInstance(parts)
+	l.spanEvents.Store(config.SpanEvents)
+	return l, nil
+}
+
+// NewWithCore builds a Logger writing to core, for outputs zlog does not configure itself.
+func NewWithCore(core zapcore.Core, opts ...zap.Option) *Logger {
+	opts = append([]zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)}, opts...)
+	return newInstance(&loggerParts{logger: zap.New(core, opts...)})
+}
 
-	// Normalize output destination
-	switch cfg.Output {
-	case "console", "file", "both":
-		// valid
-	default:
-		cfg.Output = "console"
+func newInstance(parts *loggerParts) *Logger {
+	l := &Logger{
+		hooks:      &hookRegistry{},
+		spanEvents: &atomic.Bool{},
+		parts:      parts,
+	}
+	if parts.fatal != nil {
+		parts.fatal.hooks = l.hooks
 	}
+	l.setBase(parts.logger, 1)
+	return l
+}
 
-	// Normalize format
-	if cfg.Format != "json" && cfg.Format != "console" {
-		cfg.Format = "console"
+// setBase installs base and derives the method loggers with the given caller skip.
+func (l *Logger) setBase(base *zap.Logger, skip int) {
+	l.base = base
+	l.zl = base.WithOptions(zap.AddCallerSkip(skip))
+	l.sugar = l.zl.Sugar()
+}
+
+// clone returns a copy sharing hooks, redaction and outputs.
+func (l *Logger) clone(base *zap.Logger) *Logger {
+	c := *l
+	skip := 1
+	if l.global {
+		skip = 2
 	}
+	c.setBase(base, skip)
+	return &c
+}
 
-	// Validate file path when needed
-	if (cfg.Output == "file" || cfg.Output == "both") && cfg.FilePath == "" {
-		return nil, fmt.Errorf("file path is required when output is 'file' or 'both'")
+// With returns a child logger that adds fields to every entry.
+func (l *Logger) With(fields ...Field) *Logger {
+	if len(fields) == 0 {
+		return l
 	}
+	return l.clone(l.base.With(fields...))
+}
+
+// Named returns a child logger with name appended to the logger name, separated by a period.
+func (l *Logger) Named(name string) *Logger {
+	return l.clone(l.base.Named(name))
+}
 
-	// Apply reasonable defaults for rotation settings
-	if cfg.MaxSize <= 0 {
-		cfg.MaxSize = 100 // MB
+// Zap returns the underlying zap.Logger.
+func (l *Logger) Zap() *zap.Logger {
+	return l.base
+}
+
+// Sugar returns a SugaredLogger sharing the underlying core.
+func (l *Logger) Sugar() *zap.SugaredLogger {
+	return l.base.Sugar()
+}
+
+// Sync flushes buffered entries, including those queued by asynchronous outputs.
+func (l *Logger) Sync() error {
+	return l.base.Sync()
+}
+
+// Close flushes the logger, stops its asynchronous writers and closes its
+// files and connections. Loggers derived from l share the same outputs.
+func (l *Logger) Close() error {
+	err := l.Sync()
+	l.parts.close()
+	return err
+}
+
+// enabled reports whether l writes entries at level to its outputs, taking
+// the module level of its name into account. The recent buffer, which also
+// keeps disabled levels, does not count.
+func (l *Logger) enabled(level Level) bool {
+	lvl := level.toZapCoreLevel()
+	if l.parts.modules != nil && lvl < l.parts.modules.level(l.base.Name()) {
+		return false
 	}
-	if cfg.MaxBackups < 0 {
-		cfg.MaxBackups = 10
+	if l.parts.shown != nil {
+		return l.parts.shown.Enabled(lvl)
 	}
-	if cfg.MaxAge < 0 {
-		cfg.MaxAge = 30 // days
+	return l.base.Core().Enabled(lvl)
+}
+
+// EnableSpanEvents turns recording of *Ctx entries as span events on or off
+// for l and the loggers derived from it.
+func (l *Logger) EnableSpanEvents(enabled bool) {
+	l.spanEvents.Store(enabled)
+}
+
+// globals holds the default instance and its package-level wrapper.
+type globals struct {
+	logger *Logger // returned by L
+	pkg    *Logger // used by package-level functions, one more caller frame skipped
+}
+
+var (
+	defaultGlobals atomic.Pointer[globals]
+	defaultMu      sync.Mutex
+)
+
+// ReplaceGlobals installs l as the default instance used by the package-level
+// functions and returns a function restoring the previous one. The previous
+// instance is left open.
+func ReplaceGlobals(l *Logger) func() {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	prev := defaultGlobals.Load()
+	storeGlobals(l)
+
+	return func() {
+		defaultMu.Lock()
+		defer defaultMu.Unlock()
+		defaultGlobals.Store(prev)
 	}
+}
 
-	// Resolve relative file path to absolute
-	if cfg.FilePath != "" && !filepath.IsAbs(cfg.FilePath) {
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get working directory: %w", err)
+// storeGlobals installs l as the default instance; defaultMu must be held.
+func storeGlobals(l *Logger) *globals {
+	pkg := *l
+	pkg.global = true
+	pkg.setBase(l.base, 2)
+	g := &globals{logger: l, pkg: &pkg}
+	defaultGlobals.Store(g)
+	return g
+}
+
+// isDefault reports whether l is the default instance or derived from it.
+func (l *Logger) isDefault() bool {
+	g := defaultGlobals.Load()
+	return g != nil && g.logger.hooks == l.hooks
+}
+
+// loadGlobals returns the default instance, creating it from DefaultConfig on first use.
+func loadGlobals() *globals {
+	if g := defaultGlobals.Load(); g != nil {
+		return g
+	}
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if g := defaultGlobals.Load(); g != nil {
+		return g
+	}
+	l, err := New(DefaultConfig())
+	if err != nil {
+		l = NewWithCore(zapcore.NewNopCore())
+	}
+	return storeGlobals(l)
+}
+
+// pkg returns the logger used by package-level functions.
+func pkg() *Logger {
+	return loadGlobals().pkg
+}
+
+// loggerParts are the pieces built by newLogger that the owning Logger manages.
+type loggerParts struct {
+	logger   *zap.Logger
+	writers  []*AsyncWriter
+	closers  []io.Closer
+	redactor *Redactor
+	sampler  *sampler
+	fatal    *fatalHandler
+	recent   *recentBuffer // latest entries for RecentConfig and the crash dump
+	outputs  zapcore.Core  // the outputs without level, sampling and module filters, for FlushRecent
+	shown    zapcore.Core  // the outputs with sampling and module filters, without the recent buffer
+	modules  *moduleLevels
+}
+
+// newLogger creates a new zap.Logger instance with config validation (see
+// LoggerConfig.Validate), default value filling, and path resolution.
+// A non-nil output replaces the outputs described by config.
+// internal helper, not exported
+func newLogger(config LoggerConfig, output zapcore.Core) (_ *loggerParts, err error) {
+	cfg := config
+
+	// Report every problem at once. Without Strict, values that have a
+	// sensible default are normalized instead.
+	checked := cfg
+	if output != nil {
+		checked.Outputs, checked.Output = nil, "console"
+	}
+	if err := checked.validate().err(cfg.Strict); err != nil {
+		return nil, err
+	}
+	cfg = cfg.normalized()
+
+	parts := &loggerParts{}
+	if cfg.Redact != nil {
+		if parts.redactor, err = NewRedactor(*cfg.Redact); err != nil {
+			return nil, err
 		}
-		cfg.FilePath = filepath.Join(wd, cfg.FilePath)
 	}
 
-	// Create log directory if needed
-	if cfg.FilePath != "" {
-		dir := filepath.Dir(cfg.FilePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create log directory %q: %w", dir, err)
+	// 5. Build one core per output
+	defer func() {
+		if err != nil {
+			parts.close()
 		}
+	}()
+	wrapAsync := func(ws zapcore.WriteSyncer) zapcore.WriteSyncer {
+		if cfg.Async == nil {
+			return ws
+		}
+		w := NewAsyncWriter(ws, *cfg.Async)
+		parts.writers = append(parts.writers, w)
+		return w
 	}
 
-	// 4. Build encoder config
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "ts",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		FunctionKey:    zapcore.OmitKey,
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     zapcore.ISO8601TimeEncoder,
-		EncodeDuration: zapcore.SecondsDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+	// Outputs without their own level let through everything a module may
+	// log; moduleCore then applies the level of each logger name.
+	var modules *moduleLevels
+	outCfg := cfg
+	if len(cfg.Modules) > 0 {
+		modules = newModuleLevels(cfg.Level, cfg.Modules)
+		outCfg.Level = fromZapCoreLevel(modules.min)
 	}
 
-	// 5. Build cores
+	outputs := cfg.Outputs
+	if len(outputs) == 0 {
+		outputs = legacyOutputs(cfg)
+	}
 	var cores []zapcore.Core
-	zapLevel := cfg.Level.toZapCoreLevel()
-
-	// Console output
-	if cfg.Output == "console" || cfg.Output == "both" {
-		var enc zapcore.Encoder
-		consoleEncCfg := encoderConfig
-		if cfg.Format == "json" {
-			enc = zapcore.NewJSONEncoder(consoleEncCfg)
-		} else {
-			consoleEncCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
-			enc = zapcore.NewConsoleEncoder(consoleEncCfg)
-		}
-		cores = append(cores, zapcore.NewCore(enc, zapcore.Lock(os.Stdout), zapLevel))
+	if output != nil {
+		outputs, cores = nil, []zapcore.Core{output}
 	}
-
-	// File output (always JSON)
-	if cfg.Output == "file" || cfg.Output == "both" {
-		writer := &lumberjack.Logger{
-			Filename:   cfg.FilePath,
-			MaxSize:    cfg.MaxSize,
-			MaxBackups: cfg.MaxBackups,
-			MaxAge:     cfg.MaxAge,
-			Compress:   cfg.Compress,
+	for _, o := range outputs {
+		core, closer, err := buildOutput(o, outCfg, wrapAsync)
+		if closer != nil {
+			parts.closers = append(parts.closers, closer)
 		}
-		var enc zapcore.Encoder
-		consoleEncCfg := encoderConfig
-		if cfg.Format == "json" {
-			enc = zapcore.NewJSONEncoder(consoleEncCfg)
-		} else {
-			enc = zapcore.NewConsoleEncoder(consoleEncCfg)
+		if err != nil {
+			return nil, err
 		}
-		cores = append(cores, zapcore.NewCore(enc, zapcore.AddSync(writer), zapLevel))
+		cores = append(cores, core)
 	}
 
 	if len(cores) == 0 {
 		return nil, fmt.Errorf("no valid log output configured")
 	}
 
+	// Wrap each output so per-core level checks still apply after redaction
+	// and stack deduplication
+	for i := range cores {
+		if parts.redactor != nil {
+			cores[i] = newRedactCore(cores[i], parts.redactor)
+		}
+		if cfg.DedupeStacktrace {
+			cores[i] = newDedupeStackCore(cores[i])
+		}
+	}
+
 	// 6. Build logger
+	var fields []Field
+	for k, v := range cfg.Fields {
+		fields = append(fields, zap.String(k, v))
+	}
 	core := zapcore.NewTee(cores...)
+	parts.outputs = core
+	if sc := cfg.Sampler; sc != nil || cfg.Sampling {
+		if sc == nil {
+			sc = &SamplingConfig{}
+		}
+		sampled := newSamplingCore(core, *sc, fields)
+		parts.sampler = sampled.s
+		core = sampled
+	}
+	if modules != nil {
+		core = newModuleCore(core, modules)
+	}
+	parts.shown, parts.modules = core, modules
+
+	// The recent buffer and the crash dump keep the latest entries, including
+	// levels the outputs, samplers and modules filter out
+	parts.fatal = newFatalHandler(cfg.Fatal, parts)
+	if size, lvl, ok := recentSettings(cfg.Recent, parts.fatal); ok {
+		parts.recent = newRecentBuffer(size)
+		core = zapcore.NewTee(core, newRecentCore(parts.recent, lvl, parts.redactor))
+	}
+
 	options := []zap.Option{
 		zap.AddCaller(),
-		zap.AddCallerSkip(1),
 		zap.AddStacktrace(zapcore.ErrorLevel),
 		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
-	}
-
-	if cfg.Sampling {
-		options = append(options, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
-			return zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
-		}))
+		zap.WithFatalHook(parts.fatal),
 	}
 
 	logger := zap.New(core, options...)
-	if len(cfg.Fields) > 0 {
-		var fields []Field
-		for k, v := range cfg.Fields {
-			fields = append(fields, zap.String(k, v))
-		}
+	if len(fields) > 0 {
 		logger = logger.WithOptions(zap.Fields(fields...))
 	}
 
-	return logger, nil
+	parts.logger = logger
+	return parts, nil
 }
 
-// InitLogger initializes global logger (thread-safe)
-func InitLogger(config LoggerConfig) error {
-	var err error
-	once.Do(func() {
-		globalLogger, err = newLogger(config)
-		if err == nil {
-			globalSugaredLogger = globalLogger.Sugar()
-		}
-	})
-	return err
+// close stops asynchronous writers and releases files and connections.
+func (p *loggerParts) close() {
+	if p.sampler != nil {
+		// Emit the final suppression summary while outputs are still open.
+		_ = p.sampler.Close()
+	}
+	for _, w := range p.writers {
+		_ = w.Close()
+	}
+	for _, c := range p.closers {
+		_ = c.Close()
+	}
 }
 
-// Logger returns global zap.Logger
-func Logger() *zap.Logger {
-	if globalLogger == nil {
-		once.Do(func() {
-			cfg := DefaultConfig()
-			globalLogger, _ = newLogger(cfg)
-			globalSugaredLogger = globalLogger.Sugar()
-		})
+// InitLogger builds a Logger from config and installs it as the default
+// instance. The previous default is flushed but left open: loggers taken
+// from it with L, Named or With, and slog handlers bound to it, keep
+// writing to its outputs.
+func InitLogger(config LoggerConfig) error {
+	l, err := New(config)
+	if err != nil {
+		return err
 	}
-	return globalLogger
+	defaultMu.Lock()
+	prev := defaultGlobals.Load()
+	storeGlobals(l)
+	defaultMu.Unlock()
+	if prev != nil {
+		_ = prev.logger.Sync()
+	}
+	return nil
+}
+
+// L returns the default Logger instance.
+func L() *Logger {
+	return loadGlobals().logger
+}
+
+// Zap returns the default instance's underlying zap.Logger. It replaces the
+// former zlog.Logger function, whose name is now taken by the Logger type.
+func Zap() *zap.Logger {
+	return L().Zap()
 }
 
-// Sugar returns global SugaredLogger
+// Sugar returns the default instance's SugaredLogger.
 func Sugar() *zap.SugaredLogger {
-	_ = Logger() // Trigger initialization
-	return globalSugaredLogger
+	return L().Sugar()
 }
 
 // InitDefault initializes with default configuration
@@ -204,6 +413,5 @@ func MustInitDefault() {
 
 // Sync ensures logs are flushed to disk
 func Sync() error {
-	logger := Logger() // Trigger default initialization if not already initialized
-	return logger.Sync()
+	return L().Sync()
 }
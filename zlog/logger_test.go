@@ -0,0 +1,120 @@
+package zlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// keepGlobals restores the default instance when the test ends.
+func keepGlobals(t *testing.T) {
+	prev := defaultGlobals.Load()
+	t.Cleanup(func() { defaultGlobals.Store(prev) })
+}
+
+type recordingHook struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (h *recordingHook) OnLog(level Level, msg string, fields []Field) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, msg)
+	return nil
+}
+
+func (h *recordingHook) seen(msg string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range h.msgs {
+		if m == msg {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGlobalHooksOnDefaultInstance(t *testing.T) {
+	keepGlobals(t)
+	core, _ := observer.New(zapcore.DebugLevel)
+	l := NewWithCore(core)
+	ReplaceGlobals(l)
+	other := NewWithCore(core)
+
+	hook := &recordingHook{}
+	RegisterLogHook(hook)
+
+	Info("package")
+	L().Info("default")
+	Named("payments").Info("named")
+	other.Info("other")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GinLogger())
+	r.GET("/", func(c *gin.Context) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	Go(func() { panic("boom") })
+	for deadline := time.Now().Add(2 * time.Second); !hook.seen("recovered from panic") && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, msg := range []string{"package", "default", "named", "request", "recovered from panic"} {
+		if !hook.seen(msg) {
+			t.Errorf("global hook missed %q", msg)
+		}
+	}
+	if hook.seen("other") {
+		t.Error("global hook ran for an instance that is not the default")
+	}
+}
+
+func TestInitLoggerKeepsPreviousDefaultOpen(t *testing.T) {
+	keepGlobals(t)
+	dir := t.TempDir()
+	config := func(name string) LoggerConfig {
+		return LoggerConfig{
+			Level:   InfoLevel,
+			Async:   &AsyncConfig{},
+			Outputs: []OutputConfig{{Type: "file", Path: filepath.Join(dir, name)}},
+		}
+	}
+
+	if err := InitLogger(config("a.log")); err != nil {
+		t.Fatal(err)
+	}
+	first := L()
+	defer first.Close()
+	payments := Named("payments")
+	if err := InitLogger(config("b.log")); err != nil {
+		t.Fatal(err)
+	}
+	defer L().Close()
+
+	// Loggers derived from the replaced default still reach its outputs.
+	if first.parts.writers[0].closed.Load() {
+		t.Fatal("InitLogger closed the previous default")
+	}
+	payments.Info("after replace")
+	if err := payments.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "a.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "after replace") {
+		t.Errorf("a.log = %q, want the entry logged after InitLogger", data)
+	}
+}
@@ -0,0 +1,459 @@
+package zlog
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Struct logs a struct (or pointer to one) as an object. Field names follow
+// `json` tags, including "-" and omitempty; `log:"-"` hides a field and
+// `log:"redact"` masks it when redaction is enabled. The field layout of each
+// type is computed once and cached, so repeated logging avoids most of the
+// cost of zap.Any's JSON reflection. Values implementing LogObject marshal
+// themselves, as do time.Time and types implementing json.Marshaler or
+// encoding.TextMarshaler; non-struct values fall back to Any.
+//
+// Values nested more than 32 levels deep or pointing back at themselves are
+// not walked further; a "<key>Error" field is logged in their place.
+func Struct(key string, val interface{}) Field {
+	if obj, ok := val.(LogObject); ok {
+		return zap.Object(key, obj)
+	}
+	v := reflect.ValueOf(val)
+	var path *ptrPath
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return zap.Reflect(key, nil)
+		}
+		path = &ptrPath{v.Pointer(), v.Type(), path}
+		v = v.Elem()
+	}
+	if _, ok := selfMarshaler(v); ok || v.Kind() != reflect.Struct || v.Type() == timeType {
+		return zap.Any(key, val)
+	}
+	return zap.Object(key, structObject{v: v, path: path})
+}
+
+// maxMarshalDepth bounds the nesting of structs and slices walked by Struct.
+const maxMarshalDepth = 32
+
+var (
+	errMarshalDepth = errors.New("value nested too deeply")
+	errMarshalCycle = errors.New("cycle in value")
+)
+
+// ptrPath lists the pointers followed to reach a value. It is never modified
+// once built, so a Field can be encoded by several goroutines at once.
+type ptrPath struct {
+	ptr    uintptr
+	typ    reflect.Type
+	parent *ptrPath
+}
+
+func (p *ptrPath) contains(v reflect.Value) bool {
+	for ; p != nil; p = p.parent {
+		if p.ptr == v.Pointer() && p.typ == v.Type() {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	logObjectType = reflect.TypeOf((*LogObject)(nil)).Elem()
+	durationType  = reflect.TypeOf(time.Duration(0))
+	bytesType     = reflect.TypeOf([]byte(nil))
+)
+
+// structPlans caches the field layout of struct types.
+var structPlans sync.Map // reflect.Type -> []structField
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	redact    bool
+}
+
+// structObject marshals a struct value using its cached plan.
+type structObject struct {
+	v     reflect.Value
+	depth int
+	path  *ptrPath
+}
+
+func (o structObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	red, _ := enc.(*redactEncoder)
+	for _, f := range structPlan(o.v.Type()) {
+		fv, ok := fieldByIndex(o.v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if f.redact && red != nil {
+			red.ObjectEncoder.AddString(f.name, red.r.Mask(fieldText(fv)))
+			continue
+		}
+		if err := encodeValue(enc, f.name, fv, o.depth, o.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that reports nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func structPlan(t reflect.Type) []structField {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.([]structField)
+	}
+	plan := buildStructPlan(t, nil)
+	structPlans.Store(t, plan)
+	return plan
+}
+
+// buildStructPlan lists the logged fields of t in declaration order. Fields of
+// embedded structs without a json name are promoted; on name conflicts the
+// shallowest field wins.
+func buildStructPlan(t reflect.Type, parent []int) []structField {
+	fields := collectStructFields(t, parent)
+	depth := make(map[string]int, len(fields))
+	for _, f := range fields {
+		if d, ok := depth[f.name]; !ok || len(f.index) < d {
+			depth[f.name] = len(f.index)
+		}
+	}
+	plan := fields[:0]
+	for _, f := range fields {
+		if depth[f.name] == len(f.index) {
+			plan = append(plan, f)
+			depth[f.name] = -1 // keep the first one only
+		}
+	}
+	return plan
+}
+
+func collectStructFields(t reflect.Type, parent []int) []structField {
+	if len(parent) > 8 {
+		return nil // self-embedding pointer types
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue // unexported
+		}
+		logTag := sf.Tag.Get("log")
+		if logTag == "-" {
+			continue
+		}
+		name, opts := parseJSONTag(sf.Tag.Get("json"))
+		if name == "-" && opts == "" {
+			continue
+		}
+		index := append(append([]int(nil), parent...), i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && !reflect.PtrTo(ft).Implements(logObjectType) {
+			fields = append(fields, collectStructFields(ft, index)...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue // unexported non-struct embedding
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, structField{
+			name:      name,
+			index:     index,
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+			redact:    logTag == "redact",
+		})
+	}
+	return fields
+}
+
+func parseJSONTag(tag string) (name, opts string) {
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// encodeValue adds v under key using the cheapest encoder method for its type.
+// depth and path describe the container v was found in.
+func encodeValue(enc zapcore.ObjectEncoder, key string, v reflect.Value, depth int, path *ptrPath) error {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		if obj, ok := asLogObject(v); ok {
+			return enc.AddObject(key, obj)
+		}
+		if v.Kind() == reflect.Ptr {
+			if path.contains(v) {
+				enc.AddString(key+"Error", errMarshalCycle.Error())
+				return nil
+			}
+			path = &ptrPath{v.Pointer(), v.Type(), path}
+		}
+		return encodeValue(enc, key, v.Elem(), depth, path)
+	}
+	if obj, ok := asLogObject(v); ok {
+		return enc.AddObject(key, obj)
+	}
+
+	switch v.Type() {
+	case timeType:
+		if t, ok := asTime(v); ok {
+			enc.AddTime(key, t)
+		}
+		return nil
+	case durationType:
+		enc.AddDuration(key, time.Duration(v.Int()))
+		return nil
+	case bytesType:
+		enc.AddBinary(key, v.Bytes())
+		return nil
+	}
+	if m, ok := selfMarshaler(v); ok {
+		if tm, ok := m.(encoding.TextMarshaler); ok && !isJSONMarshaler(m) {
+			text, err := tm.MarshalText()
+			if err != nil {
+				enc.AddString(key+"Error", err.Error())
+				return nil
+			}
+			enc.AddString(key, string(text))
+			return nil
+		}
+		return enc.AddReflected(key, m)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		enc.AddString(key, v.String())
+	case reflect.Bool:
+		enc.AddBool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64(key, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64(key, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AddFloat64(key, v.Float())
+	case reflect.Struct:
+		if depth >= maxMarshalDepth {
+			enc.AddString(key+"Error", errMarshalDepth.Error())
+			return nil
+		}
+		return enc.AddObject(key, structObject{v, depth + 1, path})
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return enc.AddReflected(key, nil)
+		}
+		if depth >= maxMarshalDepth {
+			enc.AddString(key+"Error", errMarshalDepth.Error())
+			return nil
+		}
+		err := enc.AddArray(key, sliceArray{v, depth + 1, path})
+		if errors.Is(err, errMarshalDepth) || errors.Is(err, errMarshalCycle) {
+			// Reported here because array elements have no key of their own.
+			enc.AddString(key+"Error", err.Error())
+			return nil
+		}
+		return err
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AddReflected(key, v.Interface())
+	}
+	return nil
+}
+
+// sliceArray marshals slices and arrays element by element.
+type sliceArray struct {
+	v     reflect.Value
+	depth int
+	path  *ptrPath
+}
+
+func (a sliceArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := 0; i < a.v.Len(); i++ {
+		if err := appendValue(enc, a.v.Index(i), a.depth, a.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendValue is encodeValue for array elements. Cycles and excessive depth
+// are returned as errors for the nearest enclosing key to report.
+func appendValue(enc zapcore.ArrayEncoder, v reflect.Value, depth int, path *ptrPath) error {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return enc.AppendReflected(nil)
+		}
+		if obj, ok := asLogObject(v); ok {
+			return enc.AppendObject(obj)
+		}
+		if v.Kind() == reflect.Ptr {
+			if path.contains(v) {
+				return errMarshalCycle
+			}
+			path = &ptrPath{v.Pointer(), v.Type(), path}
+		}
+		return appendValue(enc, v.Elem(), depth, path)
+	}
+	if obj, ok := asLogObject(v); ok {
+		return enc.AppendObject(obj)
+	}
+
+	switch v.Type() {
+	case timeType:
+		if t, ok := asTime(v); ok {
+			enc.AppendTime(t)
+		}
+		return nil
+	case durationType:
+		enc.AppendDuration(time.Duration(v.Int()))
+		return nil
+	}
+	if m, ok := selfMarshaler(v); ok {
+		if tm, ok := m.(encoding.TextMarshaler); ok && !isJSONMarshaler(m) {
+			text, err := tm.MarshalText()
+			if err != nil {
+				return err
+			}
+			enc.AppendString(string(text))
+			return nil
+		}
+		return enc.AppendReflected(m)
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		enc.AppendString(v.String())
+	case reflect.Bool:
+		enc.AppendBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AppendInt64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AppendUint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		enc.AppendFloat64(v.Float())
+	case reflect.Struct:
+		if depth >= maxMarshalDepth {
+			return errMarshalDepth
+		}
+		return enc.AppendObject(structObject{v, depth + 1, path})
+	case reflect.Slice, reflect.Array:
+		if depth >= maxMarshalDepth {
+			return errMarshalDepth
+		}
+		return enc.AppendArray(sliceArray{v, depth + 1, path})
+	default:
+		if !v.CanInterface() {
+			return nil
+		}
+		return enc.AppendReflected(v.Interface())
+	}
+	return nil
+}
+
+// selfMarshaler returns v, or a pointer to it when the methods have pointer
+// receivers, if it implements json.Marshaler or encoding.TextMarshaler. Like
+// encoding/json, pointer methods are only used on addressable values.
+func selfMarshaler(v reflect.Value) (interface{}, bool) {
+	if !v.CanInterface() {
+		return nil, false
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		if pt := reflect.PointerTo(t); pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType) {
+			return v.Addr().Interface(), true
+		}
+	}
+	return nil, false
+}
+
+func isJSONMarshaler(v interface{}) bool {
+	_, ok := v.(json.Marshaler)
+	return ok
+}
+
+// asLogObject returns v as a LogObject when its type implements one. Values
+// reached through unexported fields cannot be converted and are skipped.
+func asLogObject(v reflect.Value) (LogObject, bool) {
+	if !v.CanInterface() || !v.Type().Implements(logObjectType) {
+		return nil, false
+	}
+	obj, ok := v.Interface().(LogObject)
+	return obj, ok
+}
+
+func asTime(v reflect.Value) (time.Time, bool) {
+	if !v.CanInterface() {
+		return time.Time{}, false
+	}
+	return v.Interface().(time.Time), true
+}
+
+// isEmptyValue reports whether v is empty in the sense of json's omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// fieldText renders v for masking.
+func fieldText(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if !v.CanInterface() {
+		return ""
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(string(b), `"`)
+}
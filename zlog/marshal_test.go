@@ -0,0 +1,147 @@
+package zlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// encodeJSON logs f alone with a JSON encoder and returns the decoded entry.
+func encodeJSON(t *testing.T, f Field) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", EncodeTime: zapcore.RFC3339NanoTimeEncoder})
+	core := zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel)
+	if err := core.Write(zapcore.Entry{Message: "m"}, []Field{f}); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	return got
+}
+
+type node struct {
+	Name string
+	Next *node
+}
+
+type tree struct {
+	Name     string
+	Children []interface{}
+}
+
+type cents int
+
+func (c cents) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"%d.%02d"`, c/100, c%100)), nil
+}
+
+type color struct{ r, g, b uint8 }
+
+func (c *color) MarshalText() ([]byte, error) {
+	if c.r == 0xff && c.g == 0xff && c.b == 0xff {
+		return nil, errors.New("white is not allowed")
+	}
+	return []byte(fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)), nil
+}
+
+func TestStructCycle(t *testing.T) {
+	a := &node{Name: "a"}
+	a.Next = &node{Name: "b", Next: a}
+	got := encodeJSON(t, Struct("list", a))
+
+	list, _ := got["list"].(map[string]interface{})
+	next, _ := list["Next"].(map[string]interface{})
+	if list["Name"] != "a" || next["Name"] != "b" {
+		t.Fatalf("unexpected encoding: %v", got)
+	}
+	if next["NextError"] != errMarshalCycle.Error() {
+		t.Errorf("cycle not reported: %v", next)
+	}
+}
+
+func TestStructSliceCycle(t *testing.T) {
+	root := &tree{Name: "root"}
+	root.Children = []interface{}{root}
+	got := encodeJSON(t, Struct("tree", root))
+	tr, _ := got["tree"].(map[string]interface{})
+	if tr["ChildrenError"] != errMarshalCycle.Error() {
+		t.Errorf("cycle through a slice not reported: %v", got)
+	}
+
+	// Cycles without pointers are stopped by the depth limit.
+	s := []interface{}{nil}
+	s[0] = s
+	got = encodeJSON(t, Struct("s", struct{ S []interface{} }{s}))
+	if v, _ := got["s"].(map[string]interface{}); v["SError"] != errMarshalDepth.Error() {
+		t.Errorf("depth limit not reported: %v", got)
+	}
+}
+
+func TestStructSharedPointerIsNotACycle(t *testing.T) {
+	shared := &node{Name: "shared"}
+	got := encodeJSON(t, Struct("pair", struct{ A, B *node }{shared, shared}))
+	pair, _ := got["pair"].(map[string]interface{})
+	for _, k := range []string{"A", "B"} {
+		if v, _ := pair[k].(map[string]interface{}); v["Name"] != "shared" {
+			t.Errorf("%s = %v", k, pair[k])
+		}
+	}
+}
+
+func TestStructMarshalers(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	type order struct {
+		Created time.Time
+		Updated *time.Time
+		Amount  cents
+		Color   color
+		Bad     color
+		Addr    net.IP
+		Times   []time.Time
+	}
+	v := &order{
+		Created: ts,
+		Updated: &ts,
+		Amount:  1500,
+		Color:   color{r: 0xab},
+		Bad:     color{0xff, 0xff, 0xff},
+		Addr:    net.IPv4(10, 0, 0, 1),
+		Times:   []time.Time{ts},
+	}
+	got := encodeJSON(t, Struct("order", v))
+	o, _ := got["order"].(map[string]interface{})
+
+	want := map[string]interface{}{
+		"Created":  "2024-05-01T12:00:00Z",
+		"Updated":  "2024-05-01T12:00:00Z",
+		"Amount":   "15.00",
+		"Color":    "#ab0000",
+		"BadError": "white is not allowed",
+		"Addr":     "10.0.0.1",
+	}
+	for k, w := range want {
+		if o[k] != w {
+			t.Errorf("%s = %v, want %v", k, o[k], w)
+		}
+	}
+	if times, _ := o["Times"].([]interface{}); len(times) != 1 || times[0] != "2024-05-01T12:00:00Z" {
+		t.Errorf("Times = %v", o["Times"])
+	}
+
+	// Top-level values encode themselves too.
+	if got := encodeJSON(t, Struct("at", ts)); got["at"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("time.Time logged as %v", got["at"])
+	}
+	if got := encodeJSON(t, Struct("c", &color{r: 0x10})); got["c"] != "#100000" {
+		t.Errorf("TextMarshaler logged as %v", got["c"])
+	}
+}
@@ -0,0 +1,79 @@
+package zlog
+
+// ========== Structured Logging ==========
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.executeHooks(DebugLevel, msg, fields)
+	l.zl.Debug(msg, fields...)
+}
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.executeHooks(InfoLevel, msg, fields)
+	l.zl.Info(msg, fields...)
+}
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.executeHooks(WarnLevel, msg, fields)
+	l.zl.Warn(msg, fields...)
+}
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.executeHooks(ErrorLevel, msg, fields)
+	l.zl.Error(msg, fields...)
+}
+func (l *Logger) Panic(msg string, fields ...Field) {
+	l.executeHooks(PanicLevel, msg, fields)
+	l.zl.Panic(msg, fields...)
+}
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.executeHooks(FatalLevel, msg, fields)
+	l.zl.Fatal(msg, fields...)
+}
+
+// ========== Key-Value Logging ==========
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(DebugLevel, msg, nil)
+	l.sugar.Debugw(msg, keysAndValues...)
+}
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(InfoLevel, msg, nil)
+	l.sugar.Infow(msg, keysAndValues...)
+}
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(WarnLevel, msg, nil)
+	l.sugar.Warnw(msg, keysAndValues...)
+}
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(ErrorLevel, msg, nil)
+	l.sugar.Errorw(msg, keysAndValues...)
+}
+func (l *Logger) Panicw(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(PanicLevel, msg, nil)
+	l.sugar.Panicw(msg, keysAndValues...)
+}
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	l.executeHooks(FatalLevel, msg, nil)
+	l.sugar.Fatalw(msg, keysAndValues...)
+}
+
+// ========== Formatted Logging ==========
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.executeHooksf(DebugLevel, format, args)
+	l.sugar.Debugf(format, args...)
+}
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.executeHooksf(InfoLevel, format, args)
+	l.sugar.Infof(format, args...)
+}
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.executeHooksf(WarnLevel, format, args)
+	l.sugar.Warnf(format, args...)
+}
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.executeHooksf(ErrorLevel, format, args)
+	l.sugar.Errorf(format, args...)
+}
+func (l *Logger) Panicf(format string, args ...interface{}) {
+	l.executeHooksf(PanicLevel, format, args)
+	l.sugar.Panicf(format, args...)
+}
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.executeHooksf(FatalLevel, format, args)
+	l.sugar.Fatalf(format, args...)
+}
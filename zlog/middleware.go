@@ -0,0 +1,262 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader is the HTTP header used to propagate the request ID.
+const RequestIDHeader = "X-Request-ID"
+
+// MaxRequestIDLength bounds client-supplied request IDs; longer ones are replaced.
+const MaxRequestIDLength = 128
+
+// GinLoggerConfig configures GinLoggerWithConfig.
+type GinLoggerConfig struct {
+	// SkipPaths lists request paths that are not logged (e.g. "/healthz").
+	SkipPaths []string
+	// SlowThreshold marks requests slower than this as slow and logs them at warn level.
+	// Zero disables slow-request detection.
+	SlowThreshold time.Duration
+	// BodySampleRate is the fraction (0..1) of requests whose body is logged.
+	BodySampleRate float64
+	// MaxBodySize limits the number of body bytes captured when sampled. Default: 4096.
+	MaxBodySize int
+	// UserIDKeys are the gin.Context keys checked for the user ID,
+	// as set by jwtx auto-injection. Default: ["user_id"].
+	UserIDKeys []string
+	// UserID, if set, extracts the user ID instead of looking up UserIDKeys,
+	// e.g. to read the subject of parsed JWT claims.
+	UserID func(c *gin.Context) string
+	// RequestIDGenerator creates a request ID when the client did not send one
+	// or sent one that is not a valid request ID (see MaxRequestIDLength).
+	RequestIDGenerator func() string
+	// Logger receives the request entries. Nil means the default instance.
+	Logger *Logger
+}
+
+// DefaultGinLoggerConfig returns the configuration used by GinLogger.
+func DefaultGinLoggerConfig() GinLoggerConfig {
+	return GinLoggerConfig{
+		MaxBodySize:        4096,
+		UserIDKeys:         []string{string(UserIDKey)},
+		RequestIDGenerator: newRequestID,
+	}
+}
+
+// GinLogger returns a Gin middleware that logs every request with the default configuration.
+func GinLogger() gin.HandlerFunc {
+	return GinLoggerWithConfig(DefaultGinLoggerConfig())
+}
+
+// GinLoggerWithConfig returns a Gin middleware that propagates the request ID
+// and logs method, path, status, latency, client IP, response size and user ID.
+func GinLoggerWithConfig(conf GinLoggerConfig) gin.HandlerFunc {
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = 4096
+	}
+	if len(conf.UserIDKeys) == 0 {
+		conf.UserIDKeys = []string{string(UserIDKey)}
+	}
+	if conf.RequestIDGenerator == nil {
+		conf.RequestIDGenerator = newRequestID
+	}
+
+	skip := make(map[string]struct{}, len(conf.SkipPaths))
+	for _, p := range conf.SkipPaths {
+		skip[p] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		// The header is echoed back and logged, so only accept IDs that cannot
+		// inject headers or break log lines.
+		reqID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(reqID) {
+			reqID = conf.RequestIDGenerator()
+		}
+		c.Set(string(RequestIDKey), reqID)
+		c.Header(RequestIDHeader, reqID)
+		c.Request = c.Request.WithContext(context.WithValue(extractTraceparent(c.Request), RequestIDKey, reqID))
+
+		path := c.Request.URL.Path
+		if _, ok := skip[path]; ok {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if conf.BodySampleRate > 0 && c.Request.Body != nil && mrand.Float64() < conf.BodySampleRate {
+			body = sampleBody(c.Request, conf.MaxBodySize)
+		}
+
+		c.Next()
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+
+		fields := []Field{
+			zap.String("request_id", reqID),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.Int("status", status),
+			zap.Duration("latency", latency),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("bytes", c.Writer.Size()),
+		}
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			fields = append(fields, zap.String("query", raw))
+		}
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			fields = append(fields, zap.String("trace_id", sc.TraceID().String()))
+		}
+		userID := ""
+		if conf.UserID != nil {
+			userID = conf.UserID(c)
+		} else {
+			userID = ginUserID(c, conf.UserIDKeys)
+		}
+		if userID != "" {
+			fields = append(fields, zap.String("user_id", userID))
+		}
+		if body != nil {
+			fields = append(fields, zap.ByteString("body", body))
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.ByType(gin.ErrorTypePrivate).String()))
+		}
+
+		slow := conf.SlowThreshold > 0 && latency > conf.SlowThreshold
+		if slow {
+			fields = append(fields, zap.Bool("slow", true))
+		}
+
+		logger := conf.Logger
+		if logger == nil {
+			logger = L()
+		}
+		switch {
+		case status >= http.StatusInternalServerError:
+			logger.Error("request", fields...)
+		case status >= http.StatusBadRequest || slow:
+			logger.Warn("request", fields...)
+		default:
+			logger.Info("request", fields...)
+		}
+	}
+}
+
+// GinRecovery returns a Gin middleware that recovers from panics, logs them
+// via zlog.Error and responds with 500. The stack trace is the logger's own
+// error-level stacktrace, which still includes the panicking frames.
+func GinRecovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			// A broken connection cannot receive a response, so only log it.
+			brokenPipe := false
+			if err, ok := r.(error); ok {
+				var ne *net.OpError
+				if errors.As(err, &ne) {
+					var se *os.SyscallError
+					if errors.As(ne, &se) {
+						brokenPipe = errors.Is(se.Err, syscall.EPIPE) || errors.Is(se.Err, syscall.ECONNRESET)
+					}
+				}
+			}
+
+			// Error values keep their chain and stack; anything else is encoded as is.
+			panicField := zap.Any("panic", r)
+			if err, ok := r.(error); ok {
+				panicField = NamedErr("panic", err)
+			}
+			reqID, _ := c.Request.Context().Value(RequestIDKey).(string)
+			Error("panic recovered",
+				zap.String("request_id", reqID),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				panicField,
+			)
+
+			if brokenPipe {
+				c.Abort()
+				return
+			}
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}()
+		c.Next()
+	}
+}
+
+// ginUserID looks up the user ID injected by jwtx under one of keys.
+func ginUserID(c *gin.Context, keys []string) string {
+	for _, k := range keys {
+		if v, ok := c.Get(k); ok && v != nil {
+			if s := fmt.Sprint(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// validRequestID reports whether id is non-empty, at most MaxRequestIDLength
+// bytes and made only of [A-Za-z0-9._-].
+func validRequestID(id string) bool {
+	if id == "" || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch c := id[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// sampleBody reads up to max bytes of the request body and restores it for the handler.
+func sampleBody(r *http.Request, max int) []byte {
+	buf, err := io.ReadAll(io.LimitReader(r.Body, int64(max)))
+	if err != nil {
+		return nil
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(buf), r.Body), r.Body}
+	return buf
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// newRequestID returns a random 32-character hex string.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strings.ReplaceAll(time.Now().Format("20060102150405.000000000"), ".", "")
+	}
+	return hex.EncodeToString(b[:])
+}
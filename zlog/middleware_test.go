@@ -0,0 +1,188 @@
+package zlog
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// ginRouter returns a router logging to an observer through GinLoggerWithConfig.
+func ginRouter(conf GinLoggerConfig) (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.DebugLevel)
+	conf.Logger = NewWithCore(core)
+	r := gin.New()
+	r.Use(GinLoggerWithConfig(conf))
+	return r, logs
+}
+
+func TestGinLoggerRequestID(t *testing.T) {
+	r, logs := ginRouter(GinLoggerConfig{RequestIDGenerator: func() string { return "generated" }})
+	var seen string
+	r.GET("/", func(c *gin.Context) {
+		seen, _ = c.Request.Context().Value(RequestIDKey).(string)
+	})
+
+	tests := []struct {
+		header, want string
+	}{
+		{"", "generated"},
+		{"req-1.A_b", "req-1.A_b"},
+		{"bad id", "generated"},
+		{"x\r\nSet-Cookie: a=b", "generated"},
+		{strings.Repeat("a", MaxRequestIDLength), strings.Repeat("a", MaxRequestIDLength)},
+		{strings.Repeat("a", MaxRequestIDLength+1), "generated"},
+	}
+	for _, tt := range tests {
+		logs.TakeAll()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(RequestIDHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(RequestIDHeader); got != tt.want {
+			t.Errorf("header %q: response header = %q, want %q", tt.header, got, tt.want)
+		}
+		if seen != tt.want {
+			t.Errorf("header %q: context request ID = %q, want %q", tt.header, seen, tt.want)
+		}
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].ContextMap()["request_id"] != tt.want {
+			t.Errorf("header %q: entries = %v", tt.header, entries)
+		}
+	}
+
+	if id := newRequestID(); len(id) != 32 || !validRequestID(id) {
+		t.Errorf("newRequestID() = %q", id)
+	}
+}
+
+func TestGinLoggerSkipPaths(t *testing.T) {
+	r, logs := ginRouter(GinLoggerConfig{SkipPaths: []string{"/healthz"}})
+	r.GET("/healthz", func(c *gin.Context) {})
+	r.GET("/orders", func(c *gin.Context) {})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Header().Get(RequestIDHeader) == "" {
+		t.Error("skipped path has no request ID header")
+	}
+	if n := logs.Len(); n != 0 {
+		t.Errorf("skipped path logged %d entries", n)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders?page=2", nil))
+	entries := logs.TakeAll()
+	if len(entries) != 1 || entries[0].ContextMap()["query"] != "page=2" {
+		t.Errorf("entries = %v", entries)
+	}
+}
+
+func TestGinLoggerLevels(t *testing.T) {
+	r, logs := ginRouter(GinLoggerConfig{SlowThreshold: 5 * time.Millisecond})
+	r.GET("/ok", func(c *gin.Context) {})
+	r.GET("/slow", func(c *gin.Context) { time.Sleep(20 * time.Millisecond) })
+	r.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+
+	tests := []struct {
+		path  string
+		level zapcore.Level
+		slow  bool
+	}{
+		{"/ok", zapcore.InfoLevel, false},
+		{"/slow", zapcore.WarnLevel, true},
+		{"/missing", zapcore.WarnLevel, false},
+		{"/fail", zapcore.ErrorLevel, false},
+	}
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		entries := logs.TakeAll()
+		if len(entries) != 1 {
+			t.Fatalf("%s: %d entries", tt.path, len(entries))
+		}
+		if entries[0].Level != tt.level {
+			t.Errorf("%s: level = %v, want %v", tt.path, entries[0].Level, tt.level)
+		}
+		if _, slow := entries[0].ContextMap()["slow"]; slow != tt.slow {
+			t.Errorf("%s: slow = %v, want %v", tt.path, slow, tt.slow)
+		}
+	}
+}
+
+func TestGinLoggerBodySampling(t *testing.T) {
+	for _, rate := range []float64{0, 1} {
+		r, logs := ginRouter(GinLoggerConfig{BodySampleRate: rate, MaxBodySize: 4})
+		var read string
+		r.POST("/", func(c *gin.Context) {
+			b, _ := io.ReadAll(c.Request.Body)
+			read = string(b)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello world")))
+
+		if read != "hello world" {
+			t.Errorf("rate %v: handler read %q", rate, read)
+		}
+		body, ok := logs.TakeAll()[0].ContextMap()["body"]
+		if rate == 0 && ok {
+			t.Errorf("rate 0 logged body %v", body)
+		}
+		if rate == 1 && body != "hell" {
+			t.Errorf("rate 1 logged body %v, want the first MaxBodySize bytes", body)
+		}
+	}
+}
+
+func TestGinRecovery(t *testing.T) {
+	keepGlobals(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	ReplaceGlobals(NewWithCore(core))
+	gin.SetMode(gin.TestMode)
+
+	brokenPipe := &net.OpError{Op: "write", Net: "tcp", Err: &os.SyscallError{Syscall: "write", Err: syscall.EPIPE}}
+	tests := []struct {
+		name   string
+		value  any
+		status int
+		check  func(any) bool
+	}{
+		{"string", "boom", http.StatusInternalServerError, func(v any) bool { return v == "boom" }},
+		{"error", Wrap(io.ErrUnexpectedEOF, "read order"), http.StatusInternalServerError, func(v any) bool {
+			m, ok := v.(map[string]any)
+			return ok && m["msg"] == "read order: unexpected EOF" && m["chain"] != nil
+		}},
+		{"broken pipe", brokenPipe, http.StatusOK, func(v any) bool {
+			m, ok := v.(map[string]any)
+			return ok && strings.Contains(m["msg"].(string), "broken pipe")
+		}},
+	}
+	for _, tt := range tests {
+		r := gin.New()
+		r.Use(GinRecovery())
+		r.GET("/", func(c *gin.Context) { panic(tt.value) })
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].Level != zapcore.ErrorLevel {
+			t.Fatalf("%s: entries = %v", tt.name, entries)
+		}
+		if v := entries[0].ContextMap()["panic"]; !tt.check(v) {
+			t.Errorf("%s: panic field = %#v", tt.name, v)
+		}
+	}
+}
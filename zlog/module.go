@@ -0,0 +1,141 @@
+package zlog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Named returns a child of the default instance whose entries carry name in
+// the logger field. Its level follows LoggerConfig.Modules.
+func Named(name string) *Logger {
+	return L().Named(name)
+}
+
+// moduleLevels resolves the level of a logger name from LoggerConfig.Modules.
+//
+// A pattern without wildcards matches the name itself and every name below
+// it, so "payments" covers "payments.refund". "*" matches any run of
+// characters: "payments.*" matches only the children of payments, "*" every
+// logger. An exact match wins over all patterns; otherwise the pattern with
+// the most literal characters wins. Names matching no pattern use the
+// default level.
+type moduleLevels struct {
+	def      zapcore.Level
+	min      zapcore.Level // lowest level of any module or the default
+	patterns []modulePattern
+	cache    sync.Map // logger name -> zapcore.Level
+	cached   atomic.Int32
+}
+
+// maxModuleCache bounds the resolved names kept by moduleLevels, so names
+// built from request data cannot grow it without limit. Names beyond it are
+// matched against the patterns on every entry.
+const maxModuleCache = 1024
+
+type modulePattern struct {
+	pattern string
+	level   zapcore.Level
+	weight  int // literal characters, for choosing the most specific pattern
+}
+
+func newModuleLevels(def Level, modules map[string]Level) *moduleLevels {
+	m := &moduleLevels{def: def.toZapCoreLevel()}
+	m.min = m.def
+	for pattern, lvl := range modules {
+		if !lvl.Valid() {
+			continue // reported by Validate
+		}
+		p := modulePattern{
+			pattern: pattern,
+			level:   lvl.toZapCoreLevel(),
+			weight:  len(strings.ReplaceAll(pattern, "*", "")),
+		}
+		m.patterns = append(m.patterns, p)
+		if p.level < m.min {
+			m.min = p.level
+		}
+	}
+	return m
+}
+
+// level returns the level configured for the logger name.
+func (m *moduleLevels) level(name string) zapcore.Level {
+	if lvl, ok := m.cache.Load(name); ok {
+		return lvl.(zapcore.Level)
+	}
+	lvl, best := m.def, -1
+	for _, p := range m.patterns {
+		if p.pattern == name {
+			lvl = p.level
+			break
+		}
+		if p.weight > best && matchModule(p.pattern, name) {
+			lvl, best = p.level, p.weight
+		}
+	}
+	if m.cached.Load() < maxModuleCache {
+		if _, loaded := m.cache.LoadOrStore(name, lvl); !loaded {
+			m.cached.Add(1)
+		}
+	}
+	return lvl
+}
+
+// matchModule reports whether name matches pattern as described on moduleLevels.
+func matchModule(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return name == pattern || strings.HasPrefix(name, pattern+".")
+	}
+	return matchWildcard(pattern, name)
+}
+
+// matchWildcard matches name against pattern where "*" matches any run of
+// characters, including none.
+func matchWildcard(pattern, name string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	name = name[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(name, part)
+		if i < 0 {
+			return false
+		}
+		name = name[i+len(part):]
+	}
+	return len(parts) > 1 && strings.HasSuffix(name, last)
+}
+
+// moduleCore applies the level of each entry's logger name before the
+// wrapped core sees it. The outputs below are built with the lowest module
+// level, so this is the only place per-module levels are decided.
+type moduleCore struct {
+	zapcore.Core
+	levels *moduleLevels
+}
+
+func newModuleCore(core zapcore.Core, levels *moduleLevels) zapcore.Core {
+	return &moduleCore{Core: core, levels: levels}
+}
+
+// Enabled reports whether some module logs at lvl, so zap does not skip
+// entries before Check sees their logger name.
+func (c *moduleCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= c.levels.min && c.Core.Enabled(lvl)
+}
+
+func (c *moduleCore) With(fields []Field) zapcore.Core {
+	return &moduleCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.levels.level(ent.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,55 @@
+package zlog
+
+import (
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestModuleLevels(t *testing.T) {
+	m := newModuleLevels(InfoLevel, map[string]Level{
+		"payments":        WarnLevel,
+		"payments.refund": DebugLevel,
+		"db.*":            ErrorLevel,
+		"*.audit":         DebugLevel,
+	})
+	tests := []struct {
+		name string
+		want zapcore.Level
+	}{
+		{"", zapcore.InfoLevel},
+		{"orders", zapcore.InfoLevel},
+		{"payments", zapcore.WarnLevel},
+		{"payments.card", zapcore.WarnLevel},
+		{"payments.refund", zapcore.DebugLevel},
+		{"db", zapcore.InfoLevel},
+		{"db.pool", zapcore.ErrorLevel},
+		{"db.audit", zapcore.DebugLevel},
+	}
+	for _, tt := range tests {
+		// The second lookup is served from the cache.
+		for i := 0; i < 2; i++ {
+			if got := m.level(tt.name); got != tt.want {
+				t.Errorf("level(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestModuleLevelsCacheBounded(t *testing.T) {
+	m := newModuleLevels(InfoLevel, map[string]Level{"db.*": ErrorLevel})
+	for i := 0; i < 3*maxModuleCache; i++ {
+		if got := m.level("db." + strconv.Itoa(i)); got != zapcore.ErrorLevel {
+			t.Fatalf("level = %v", got)
+		}
+	}
+	n := 0
+	m.cache.Range(func(_, _ interface{}) bool { n++; return true })
+	if n > maxModuleCache {
+		t.Errorf("cache holds %d names, limit %d", n, maxModuleCache)
+	}
+	if got := m.level("db." + strconv.Itoa(3*maxModuleCache)); got != zapcore.ErrorLevel {
+		t.Errorf("uncached name resolved to %v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package zlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RecentConfig keeps the latest entries in memory, including levels the
+// outputs filter out, for RecentHandler and FlushRecent.
+type RecentConfig struct {
+	Size  int   `yaml:"size"`  // entries kept, default 1000
+	Level Level `yaml:"level"` // lowest level kept, default debug
+}
+
+// recentSettings returns the size and lowest level of the buffer shared by
+// the Recent config and the crash dump, and whether either needs one.
+func recentSettings(rc *RecentConfig, fatal *fatalHandler) (int, zapcore.Level, bool) {
+	size, lvl, ok := 0, zapcore.FatalLevel, false
+	if rc != nil {
+		size, lvl, ok = rc.Size, zapcore.DebugLevel, true
+		if size <= 0 {
+			size = 1000
+		}
+		if rc.Level.Valid() {
+			lvl = rc.Level.toZapCoreLevel()
+		}
+	}
+	if fatal.cfg.CrashDump != "" {
+		size = max(size, fatal.cfg.CrashDumpSize)
+		lvl = min(lvl, fatal.cfg.CrashDumpLevel.toZapCoreLevel())
+		ok = true
+	}
+	return size, lvl, ok
+}
+
+// recentEntry is an entry kept by recentBuffer, with the fields added by
+// With before the entry's own fields.
+type recentEntry struct {
+	ent     zapcore.Entry
+	fields  []Field
+	seq     uint64      // position in the buffer, from 1
+	written bool        // accepted by an output when it was logged
+	flushed atomic.Bool // written to the outputs by FlushRecent
+}
+
+// recentBuffer is a fixed-size ring of the latest entries. Writers claim a
+// slot with an atomic counter and never block each other or readers.
+type recentBuffer struct {
+	slots []atomic.Pointer[recentEntry]
+	next  atomic.Uint64
+}
+
+func newRecentBuffer(size int) *recentBuffer {
+	return &recentBuffer{slots: make([]atomic.Pointer[recentEntry], size)}
+}
+
+func (b *recentBuffer) add(e *recentEntry) {
+	e.seq = b.next.Add(1)
+	b.slots[(e.seq-1)%uint64(len(b.slots))].Store(e)
+}
+
+// snapshot returns the buffered entries, oldest first. Slots overwritten or
+// not yet filled while it runs are skipped.
+func (b *recentBuffer) snapshot() []*recentEntry {
+	end := b.next.Load()
+	size := uint64(len(b.slots))
+	start := uint64(1)
+	if end > size {
+		start = end - size + 1
+	}
+	out := make([]*recentEntry, 0, end-start+1)
+	for seq := start; seq <= end; seq++ {
+		if e := b.slots[(seq-1)%size].Load(); e != nil && e.seq == seq {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// recentCore records entries into a recentBuffer. It is the last core of the
+// logger's tee, so the checked entry it receives already holds the outputs
+// that accepted the entry; written records whether there were any.
+type recentCore struct {
+	zapcore.LevelEnabler
+	buf      *recentBuffer
+	redactor *Redactor
+	fields   []Field
+	written  bool
+	shown    *recentCore // copy with written set, used when an output accepted the entry
+}
+
+func newRecentCore(buf *recentBuffer, enabler zapcore.LevelEnabler, redactor *Redactor) zapcore.Core {
+	return (&recentCore{LevelEnabler: enabler, buf: buf, redactor: redactor}).withFields(nil)
+}
+
+func (c *recentCore) withFields(fields []Field) *recentCore {
+	if c.redactor != nil {
+		fields = c.redactor.RedactFields(fields)
+	}
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+	clone.written = false
+	shown := clone
+	shown.written = true
+	clone.shown = &shown
+	return &clone
+}
+
+func (c *recentCore) With(fields []Field) zapcore.Core {
+	return c.withFields(fields)
+}
+
+func (c *recentCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if ce != nil {
+		return ce.AddCore(ent, c.shown)
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *recentCore) Write(ent zapcore.Entry, fields []Field) error {
+	if c.redactor != nil {
+		ent.Message = c.redactor.RedactString(ent.Message)
+		fields = c.redactor.RedactFields(fields)
+	}
+	all := make([]Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	c.buf.add(&recentEntry{ent: ent, fields: append(all, fields...), written: c.written})
+	return nil
+}
+
+func (c *recentCore) Sync() error {
+	return nil
+}
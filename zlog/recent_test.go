@@ -0,0 +1,35 @@
+package zlog
+
+import (
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecentBufferSnapshot(t *testing.T) {
+	tests := []struct {
+		size, added int
+		want        []string
+	}{
+		{3, 0, nil},
+		{3, 2, []string{"m1", "m2"}},
+		{3, 3, []string{"m1", "m2", "m3"}},
+		{3, 5, []string{"m3", "m4", "m5"}},
+		{3, 7, []string{"m5", "m6", "m7"}},
+		{1, 4, []string{"m4"}},
+	}
+	for _, tt := range tests {
+		b := newRecentBuffer(tt.size)
+		for i := 1; i <= tt.added; i++ {
+			b.add(&recentEntry{ent: zapcore.Entry{Message: fmt.Sprintf("m%d", i)}})
+		}
+		var got []string
+		for _, e := range b.snapshot() {
+			got = append(got, e.ent.Message)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("size %d after %d entries: snapshot = %v, want %v", tt.size, tt.added, got, tt.want)
+		}
+	}
+}
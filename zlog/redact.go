@@ -0,0 +1,610 @@
+package zlog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Masking strategies for RedactConfig.Strategy.
+const (
+	MaskFull    = "full"    // replace the whole value with RedactConfig.Mask
+	MaskPartial = "partial" // keep the last RedactConfig.KeepLast characters
+)
+
+// Built-in value patterns usable in RedactConfig.Patterns.
+const (
+	PatternBearer   = "bearer"    // Authorization bearer tokens
+	PatternJWT      = "jwt"       // bare JSON Web Tokens
+	PatternEmail    = "email"     // email addresses
+	PatternCNMobile = "cn_mobile" // mainland China mobile numbers
+	PatternCNID     = "cn_id"     // mainland China resident ID numbers
+)
+
+// builtinPatterns maps pattern names to expressions. When an expression has a
+// capture group only the first group is masked, e.g. the token after "Bearer ".
+var builtinPatterns = map[string]string{
+	PatternBearer:   `(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	PatternJWT:      `\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	PatternEmail:    `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+	PatternCNID:     `\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`,
+	PatternCNMobile: `(?:(?:\+|\b)86|\b)1[3-9]\d{9}\b`,
+}
+
+// defaultRedactPatterns is the application order of built-in patterns;
+// ID numbers go before mobile numbers so their digits are not half-matched.
+var defaultRedactPatterns = []string{PatternBearer, PatternJWT, PatternEmail, PatternCNID, PatternCNMobile}
+
+var defaultRedactKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "access_token", "refresh_token",
+	"authorization", "api_key", "apikey", "cookie", "set_cookie",
+}
+
+// RedactConfig configures masking of sensitive data before it reaches any output.
+type RedactConfig struct {
+	// Keys are field names whose values are always masked, compared case-insensitively
+	// with '-' treated as '_'. Nil means the default list (password, token, authorization, ...).
+	Keys []string `yaml:"keys"`
+	// Patterns are built-in pattern names (bearer, jwt, email, cn_mobile, cn_id) or
+	// custom regular expressions applied to messages and string values. Nil means all built-ins.
+	Patterns []string `yaml:"patterns"`
+	// Strategy is "full" (default) or "partial".
+	Strategy string `yaml:"strategy"`
+	// KeepLast is the number of trailing characters kept by the partial strategy. Default: 4.
+	KeepLast int `yaml:"keep_last"`
+	// Mask replaces values under the full strategy. Default: "******".
+	Mask string `yaml:"mask"`
+}
+
+// DefaultRedactConfig returns a RedactConfig with the default keys and all built-in patterns.
+func DefaultRedactConfig() RedactConfig {
+	return RedactConfig{
+		Keys:     append([]string(nil), defaultRedactKeys...),
+		Patterns: append([]string(nil), defaultRedactPatterns...),
+		Strategy: MaskFull,
+		KeepLast: 4,
+		Mask:     "******",
+	}
+}
+
+// Redactor masks sensitive keys and values. It is safe for concurrent use.
+type Redactor struct {
+	keys     map[string]struct{}
+	patterns []*regexp.Regexp
+	partial  bool
+	keepLast int
+	mask     string
+
+	types sync.Map // reflect.Type -> bool, whether values of the type contain sensitive fields
+}
+
+// NewRedactor compiles cfg into a Redactor.
+func NewRedactor(cfg RedactConfig) (*Redactor, error) {
+	r := &Redactor{
+		keys:     make(map[string]struct{}),
+		keepLast: cfg.KeepLast,
+		mask:     cfg.Mask,
+	}
+	switch cfg.Strategy {
+	case "", MaskFull:
+	case MaskPartial:
+		r.partial = true
+	default:
+		return nil, fmt.Errorf("unknown redact strategy %q", cfg.Strategy)
+	}
+	if r.keepLast <= 0 {
+		r.keepLast = 4
+	}
+	if r.mask == "" {
+		r.mask = "******"
+	}
+
+	keys := cfg.Keys
+	if keys == nil {
+		keys = defaultRedactKeys
+	}
+	for _, k := range keys {
+		r.keys[normalizeRedactKey(k)] = struct{}{}
+	}
+
+	patterns := cfg.Patterns
+	if patterns == nil {
+		patterns = defaultRedactPatterns
+	}
+	for _, p := range patterns {
+		expr, ok := builtinPatterns[p]
+		if !ok {
+			expr = p
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+func normalizeRedactKey(k string) string {
+	return strings.ReplaceAll(strings.ToLower(k), "-", "_")
+}
+
+// IsSensitiveKey reports whether values logged under key are always masked.
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	_, ok := r.keys[normalizeRedactKey(key)]
+	return ok
+}
+
+// Mask masks s according to the configured strategy.
+func (r *Redactor) Mask(s string) string {
+	if !r.partial {
+		return r.mask
+	}
+	n := utf8.RuneCountInString(s)
+	if n <= r.keepLast {
+		return strings.Repeat("*", n)
+	}
+	runes := []rune(s)
+	return strings.Repeat("*", n-r.keepLast) + string(runes[n-r.keepLast:])
+}
+
+// RedactString masks every pattern match in s.
+func (r *Redactor) RedactString(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() == 0 {
+			s = re.ReplaceAllStringFunc(s, r.Mask)
+			continue
+		}
+		s = replaceFirstGroup(re, s, r.Mask)
+	}
+	return s
+}
+
+func replaceFirstGroup(re *regexp.Regexp, s string, mask func(string) string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[2] < 0 {
+			continue
+		}
+		b.WriteString(s[last:m[2]])
+		b.WriteString(mask(s[m[2]:m[3]]))
+		last = m[3]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// RedactFields returns fields with sensitive keys and values masked.
+// The input slice is not modified.
+func (r *Redactor) RedactFields(fields []Field) []Field {
+	if len(fields) == 0 {
+		return fields
+	}
+	out := make([]Field, len(fields))
+	for i, f := range fields {
+		out[i] = r.field(f)
+	}
+	return out
+}
+
+func (r *Redactor) field(f Field) Field {
+	switch f.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return f
+	}
+	if r.IsSensitiveKey(f.Key) {
+		return zap.String(f.Key, r.Mask(fieldValueString(f)))
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = r.RedactString(f.String)
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			if s := r.RedactString(string(b)); s != string(b) {
+				return zap.String(f.Key, s)
+			}
+		}
+	case zapcore.ErrorType, zapcore.StringerType:
+		if len(r.patterns) == 0 {
+			return f
+		}
+		s := fieldValueString(f)
+		if red := r.RedactString(s); red != s {
+			return zap.String(f.Key, red)
+		}
+	case zapcore.ReflectType:
+		f.Interface = r.Value(f.Interface)
+	case zapcore.ObjectMarshalerType:
+		if obj, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+			f.Interface = redactObject{obj, r}
+		}
+	case zapcore.ArrayMarshalerType:
+		if arr, ok := f.Interface.(zapcore.ArrayMarshaler); ok {
+			f.Interface = redactArray{arr, r}
+		}
+	}
+	return f
+}
+
+// fieldValueString renders the value of f the way an encoder would.
+func fieldValueString(f Field) string {
+	if f.Type == zapcore.StringType {
+		return f.String
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	for _, v := range enc.Fields {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// maxRedactDepth bounds the reflection walk over nested values.
+const maxRedactDepth = 8
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	errorInterfaceType  = reflect.TypeOf((*error)(nil)).Elem()
+	stringerInterfaceTy = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// Value returns v with sensitive struct fields and map entries masked. Struct fields
+// tagged `log:"redact"` or named like a sensitive key are masked; structs are
+// returned as maps keyed by their json names. Values that need no change are
+// returned as-is.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !r.needsWalk(rv.Type()) {
+		return v
+	}
+	return r.walk(rv, 0)
+}
+
+func (r *Redactor) needsWalk(t reflect.Type) bool {
+	if len(r.patterns) > 0 {
+		switch t.Kind() {
+		case reflect.String, reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer, reflect.Interface:
+			return true
+		}
+		return false
+	}
+	if cached, ok := r.types.Load(t); ok {
+		return cached.(bool)
+	}
+	sensitive := r.typeSensitive(t, map[reflect.Type]bool{})
+	r.types.Store(t, sensitive)
+	return sensitive
+}
+
+// typeSensitive reports whether t may contain values that need masking.
+func (r *Redactor) typeSensitive(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return r.typeSensitive(t.Elem(), seen)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String || r.typeSensitive(t.Elem(), seen)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		if opaqueType(t) {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if sf.Tag.Get("log") == "redact" || r.IsSensitiveKey(jsonFieldName(sf)) {
+				return true
+			}
+			if r.typeSensitive(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// opaqueType reports whether values of t are encoded by their own marshaling
+// method and therefore left untouched.
+func opaqueType(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	for _, it := range []reflect.Type{jsonMarshalerType, textMarshalerType, errorInterfaceType, stringerInterfaceTy} {
+		if t.Implements(it) || reflect.PointerTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) walk(v reflect.Value, depth int) interface{} {
+	if depth > maxRedactDepth {
+		return safeInterface(v)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.walk(v.Elem(), depth+1)
+	case reflect.String:
+		return r.RedactString(v.String())
+	case reflect.Struct:
+		if opaqueType(v.Type()) {
+			return safeInterface(v)
+		}
+		m := make(map[string]interface{}, v.NumField())
+		r.walkStruct(v, m, depth)
+		return m
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return safeInterface(v)
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			if r.IsSensitiveKey(k) {
+				m[k] = r.Mask(fmt.Sprint(safeInterface(iter.Value())))
+				continue
+			}
+			m[k] = r.walk(iter.Value(), depth+1)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return safeInterface(v)
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = r.walk(v.Index(i), depth+1)
+		}
+		return out
+	default:
+		return safeInterface(v)
+	}
+}
+
+func (r *Redactor) walkStruct(v reflect.Value, m map[string]interface{}, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := jsonFieldName(sf)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		// Embedded structs without an explicit json name are flattened, as encoding/json does.
+		if sf.Anonymous && sf.Tag.Get("json") == "" {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && !opaqueType(fv.Type()) {
+				r.walkStruct(fv, m, depth+1)
+				continue
+			}
+		}
+		if sf.Tag.Get("log") == "redact" || r.IsSensitiveKey(name) {
+			m[name] = r.Mask(fmt.Sprint(safeInterface(fv)))
+			continue
+		}
+		m[name] = r.walk(fv, depth+1)
+	}
+}
+
+func jsonFieldName(sf reflect.StructField) string {
+	tag := sf.Tag.Get("json")
+	if idx := strings.Index(tag, ","); idx != -1 {
+		tag = tag[:idx]
+	}
+	if tag == "" {
+		return sf.Name
+	}
+	return tag
+}
+
+func safeInterface(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// redactObject masks the keys and values that an object marshaler adds.
+type redactObject struct {
+	obj zapcore.ObjectMarshaler
+	r   *Redactor
+}
+
+func (o redactObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return o.obj.MarshalLogObject(&redactEncoder{ObjectEncoder: enc, r: o.r})
+}
+
+type redactArray struct {
+	arr zapcore.ArrayMarshaler
+	r   *Redactor
+}
+
+func (a redactArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	return a.arr.MarshalLogArray(&redactArrayEncoder{ArrayEncoder: enc, r: a.r})
+}
+
+// redactEncoder is an ObjectEncoder that masks values under sensitive keys and
+// pattern matches in strings before passing them on. Struct also uses it to
+// find fields tagged `log:"redact"`.
+type redactEncoder struct {
+	zapcore.ObjectEncoder
+	r *Redactor
+}
+
+// masked adds the masked value under key when key is sensitive.
+func (e *redactEncoder) masked(key string, val interface{}) bool {
+	if !e.r.IsSensitiveKey(key) {
+		return false
+	}
+	e.ObjectEncoder.AddString(key, e.r.Mask(fmt.Sprint(val)))
+	return true
+}
+
+func (e *redactEncoder) AddString(key, val string) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddString(key, e.r.RedactString(val))
+	}
+}
+
+func (e *redactEncoder) AddByteString(key string, val []byte) {
+	e.AddString(key, string(val))
+}
+
+func (e *redactEncoder) AddBinary(key string, val []byte) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddBinary(key, val)
+	}
+}
+
+func (e *redactEncoder) AddBool(key string, val bool) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddBool(key, val)
+	}
+}
+
+func (e *redactEncoder) AddInt64(key string, val int64) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddInt64(key, val)
+	}
+}
+
+func (e *redactEncoder) AddInt(key string, val int)     { e.AddInt64(key, int64(val)) }
+func (e *redactEncoder) AddInt32(key string, val int32) { e.AddInt64(key, int64(val)) }
+func (e *redactEncoder) AddInt16(key string, val int16) { e.AddInt64(key, int64(val)) }
+func (e *redactEncoder) AddInt8(key string, val int8)   { e.AddInt64(key, int64(val)) }
+
+func (e *redactEncoder) AddUint64(key string, val uint64) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddUint64(key, val)
+	}
+}
+
+func (e *redactEncoder) AddUint(key string, val uint)     { e.AddUint64(key, uint64(val)) }
+func (e *redactEncoder) AddUint32(key string, val uint32) { e.AddUint64(key, uint64(val)) }
+func (e *redactEncoder) AddUint16(key string, val uint16) { e.AddUint64(key, uint64(val)) }
+func (e *redactEncoder) AddUint8(key string, val uint8)   { e.AddUint64(key, uint64(val)) }
+
+func (e *redactEncoder) AddFloat64(key string, val float64) {
+	if !e.masked(key, val) {
+		e.ObjectEncoder.AddFloat64(key, val)
+	}
+}
+
+func (e *redactEncoder) AddFloat32(key string, val float32) { e.AddFloat64(key, float64(val)) }
+
+func (e *redactEncoder) AddReflected(key string, val interface{}) error {
+	if e.r.IsSensitiveKey(key) {
+		e.ObjectEncoder.AddString(key, e.r.mask)
+		return nil
+	}
+	return e.ObjectEncoder.AddReflected(key, e.r.Value(val))
+}
+
+func (e *redactEncoder) AddObject(key string, obj zapcore.ObjectMarshaler) error {
+	if e.r.IsSensitiveKey(key) {
+		e.ObjectEncoder.AddString(key, e.r.mask)
+		return nil
+	}
+	return e.ObjectEncoder.AddObject(key, redactObject{obj, e.r})
+}
+
+func (e *redactEncoder) AddArray(key string, arr zapcore.ArrayMarshaler) error {
+	if e.r.IsSensitiveKey(key) {
+		e.ObjectEncoder.AddString(key, e.r.mask)
+		return nil
+	}
+	return e.ObjectEncoder.AddArray(key, redactArray{arr, e.r})
+}
+
+// redactArrayEncoder masks pattern matches in array elements.
+type redactArrayEncoder struct {
+	zapcore.ArrayEncoder
+	r *Redactor
+}
+
+func (e *redactArrayEncoder) AppendString(val string) {
+	e.ArrayEncoder.AppendString(e.r.RedactString(val))
+}
+
+func (e *redactArrayEncoder) AppendByteString(val []byte) {
+	e.ArrayEncoder.AppendString(e.r.RedactString(string(val)))
+}
+
+func (e *redactArrayEncoder) AppendReflected(val interface{}) error {
+	return e.ArrayEncoder.AppendReflected(e.r.Value(val))
+}
+
+func (e *redactArrayEncoder) AppendObject(obj zapcore.ObjectMarshaler) error {
+	return e.ArrayEncoder.AppendObject(redactObject{obj, e.r})
+}
+
+func (e *redactArrayEncoder) AppendArray(arr zapcore.ArrayMarshaler) error {
+	return e.ArrayEncoder.AppendArray(redactArray{arr, e.r})
+}
+
+// redactCore masks messages and fields before handing them to the wrapped core.
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+func newRedactCore(core zapcore.Core, r *Redactor) zapcore.Core {
+	return &redactCore{Core: core, r: r}
+}
+
+func (c *redactCore) With(fields []Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.r.RedactFields(fields)), r: c.r}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []Field) error {
+	ent.Message = c.r.RedactString(ent.Message)
+	return c.Core.Write(ent, c.r.RedactFields(fields))
+}
@@ -0,0 +1,187 @@
+package zlog
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestRedactor(t *testing.T, cfg RedactConfig) *Redactor {
+	t.Helper()
+	r, err := NewRedactor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// redactLogger returns a logger redacting with cfg into an observer.
+func redactLogger(t *testing.T, cfg RedactConfig) (*Logger, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	l, err := NewWithCoreConfig(core, LoggerConfig{Level: DebugLevel, Redact: &cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, logs
+}
+
+func TestRedactStringPatterns(t *testing.T) {
+	r := newTestRedactor(t, DefaultRedactConfig())
+	tests := []struct {
+		in, want string
+	}{
+		{"call 13812345678 now", "call ****** now"},
+		{"call +8613812345678", "call ******"},
+		{"call 8613812345678", "call ******"},
+		{"not a mobile 12812345678", "not a mobile 12812345678"},
+		{"too long 138123456789", "too long 138123456789"},
+		{"id 110101199003074518 ok", "id ****** ok"},
+		{"id 11010119900307451X", "id ******"},
+		{"bad month 110101199013074518", "bad month 110101199013074518"},
+		{"mail bob@example.com", "mail ******"},
+		{"Authorization: Bearer abc.def-ghi", "Authorization: Bearer ******"},
+		{"order 20240101", "order 20240101"},
+	}
+	for _, tt := range tests {
+		if got := r.RedactString(tt.in); got != tt.want {
+			t.Errorf("RedactString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactPartial(t *testing.T) {
+	cfg := DefaultRedactConfig()
+	cfg.Strategy = MaskPartial
+	r := newTestRedactor(t, cfg)
+	tests := []struct {
+		in, want string
+	}{
+		{"13812345678", "*******5678"},
+		{"abcd", "****"},
+		{"ab", "**"},
+		{"张三丰李四五", "**丰李四五"},
+	}
+	for _, tt := range tests {
+		if got := r.Mask(tt.in); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := r.RedactString("id 110101199003074518, tel 13812345678"); got != "id **************4518, tel *******5678" {
+		t.Errorf("partial RedactString = %q", got)
+	}
+
+	cfg.KeepLast = 2
+	if got := newTestRedactor(t, cfg).Mask("secret"); got != "****et" {
+		t.Errorf("KeepLast 2: %q", got)
+	}
+	cfg.Strategy = "half"
+	if _, err := NewRedactor(cfg); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
+
+type redactAddress struct {
+	City  string `json:"city"`
+	Phone string `json:"phone" log:"redact"`
+}
+
+type redactUser struct {
+	Name     string            `json:"name"`
+	IDCard   string            `json:"id_card" log:"redact"`
+	Password string            `json:"password"`
+	Note     string            `json:"note"`
+	Address  *redactAddress    `json:"address"`
+	Labels   map[string]string `json:"labels"`
+	internal string
+}
+
+func TestRedactAnyStructTags(t *testing.T) {
+	cfg := DefaultRedactConfig()
+	cfg.Strategy = MaskPartial
+	l, logs := redactLogger(t, cfg)
+
+	u := redactUser{
+		Name:     "alice",
+		IDCard:   "110101199003074518",
+		Password: "hunter2",
+		Note:     "call 13812345678",
+		Address:  &redactAddress{City: "Beijing", Phone: "010-12345678"},
+		Labels:   map[string]string{"token": "abcdef", "team": "core"},
+		internal: "x",
+	}
+	l.Info("user", Any("user", u), Any("users", []redactUser{u}))
+
+	ctx := logs.TakeAll()[0].ContextMap()
+	got, ok := ctx["user"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("user = %#v", ctx["user"])
+	}
+	want := map[string]interface{}{
+		"name":     "alice",
+		"id_card":  "**************4518",
+		"password": "***ter2",
+		"note":     "call *******5678",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("user.%s = %v, want %v", k, got[k], v)
+		}
+	}
+	addr := got["address"].(map[string]interface{})
+	if addr["city"] != "Beijing" || addr["phone"] != "********5678" {
+		t.Errorf("address = %v", addr)
+	}
+	labels := got["labels"].(map[string]interface{})
+	if labels["token"] != "**cdef" || labels["team"] != "core" {
+		t.Errorf("labels = %v", labels)
+	}
+	if _, ok := got["internal"]; ok {
+		t.Error("unexported field logged")
+	}
+	if users := ctx["users"].([]interface{}); users[0].(map[string]interface{})["id_card"] != "**************4518" {
+		t.Errorf("users = %v", users)
+	}
+	if u.IDCard != "110101199003074518" {
+		t.Error("redaction modified the logged value")
+	}
+}
+
+func TestRedactPrintfVariants(t *testing.T) {
+	l, logs := redactLogger(t, DefaultRedactConfig())
+	ctx := context.Background()
+
+	l.Debugf("debug %s", "13812345678")
+	l.Infof("login %s", "bob@example.com")
+	l.Warnf("id %s", "110101199003074518")
+	l.Errorf("auth %s", "Bearer abc.def")
+	l.InfofCtx(ctx, "ctx %s", "13812345678")
+	l.ErrorfCtx(ctx, "ctx %s", "13812345678")
+	l.Infow("sugared 13812345678", "password", "hunter2", "note", "bob@example.com")
+
+	want := []string{"debug ******", "login ******", "id ******", "auth Bearer ******", "ctx ******", "ctx ******", "sugared ******"}
+	entries := logs.TakeAll()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Message != want[i] {
+			t.Errorf("entry %d: message = %q, want %q", i, e.Message, want[i])
+		}
+	}
+	if m := entries[len(entries)-1].ContextMap(); m["password"] != "******" || m["note"] != "******" {
+		t.Errorf("sugared fields = %v", m)
+	}
+}
+
+func TestRedactWithFields(t *testing.T) {
+	l, logs := redactLogger(t, DefaultRedactConfig())
+	l.With(String("api_key", "k-123"), String("contact", "13812345678")).Info("ok")
+
+	m := logs.TakeAll()[0].ContextMap()
+	if m["api_key"] != "******" || m["contact"] != "******" {
+		t.Errorf("fields = %v", m)
+	}
+}
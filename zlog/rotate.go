@@ -0,0 +1,557 @@
+package zlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Rotation intervals for RotationConfig.Interval.
+const (
+	RotateDaily  = "daily"
+	RotateHourly = "hourly"
+	RotateNever  = "never" // one file without rotation or retention, e.g. when logrotate manages it
+)
+
+// Compression algorithms for rotated files.
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// Layouts of the time stamp embedded in rotated file names.
+const (
+	dailyLayout  = "2006-01-02"
+	hourlyLayout = "2006-01-02-15"
+	backupLayout = "2006-01-02T15-04-05.000"
+)
+
+// RotationConfig configures time- and size-based rotation of a log file.
+//
+// With an Interval the writer logs straight into dated files such as
+// app-2026-10-17.log (daily) or app-2026-10-17-15.log (hourly); files that
+// reach MaxSize within a period continue as app-2026-10-17.1.log and so on.
+// Without an Interval the file rotates by size only and backups are named
+// app-2026-10-17T15-04-05.000.log. RotateNever writes app.log forever and
+// ignores the size and retention limits.
+type RotationConfig struct {
+	Interval     string `yaml:"interval"`       // daily、hourly、never; empty rotates by size only
+	Timezone     string `yaml:"timezone"`       // IANA name such as Asia/Shanghai; default local time
+	MaxSize      int    `yaml:"max_size"`       // MB per file; 0 means no size limit
+	MaxBackups   int    `yaml:"max_backups"`    // rotated files kept; 0 keeps all
+	MaxAge       int    `yaml:"max_age"`        // days rotated files are kept; 0 keeps forever
+	MaxTotalSize int    `yaml:"max_total_size"` // MB disk budget for all files of this log; 0 means unlimited
+	Compression  string `yaml:"compression"`    // gzip、zstd; empty leaves rotated files uncompressed
+}
+
+// RotatingWriter is an io.WriteCloser that rotates its file by time and size.
+// Compression and retention of rotated files run in a background goroutine.
+type RotatingWriter struct {
+	cfg    RotationConfig
+	loc    *time.Location
+	dir    string
+	prefix string // file name without extension
+	ext    string
+	now    func() time.Time
+
+	mu        sync.Mutex
+	file      *os.File
+	name      string // path of the active file
+	size      int64
+	periodEnd time.Time
+	seq       int
+	closed    bool
+
+	mill     chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+	closeErr error
+	once     sync.Once
+}
+
+// NewRotatingWriter opens (or creates) the log file at filename, creating its
+// directory if needed.
+func NewRotatingWriter(filename string, cfg RotationConfig) (*RotatingWriter, error) {
+	return newRotatingWriter(filename, cfg, time.Now)
+}
+
+// newRotatingWriter is NewRotatingWriter with the clock deciding periods,
+// backup names and file ages.
+func newRotatingWriter(filename string, cfg RotationConfig, now func() time.Time) (*RotatingWriter, error) {
+	switch cfg.Interval {
+	case "", RotateDaily, RotateHourly, RotateNever:
+	default:
+		return nil, fmt.Errorf("unknown rotation interval %q", cfg.Interval)
+	}
+	switch cfg.Compression {
+	case "", CompressGzip, CompressZstd:
+	default:
+		return nil, fmt.Errorf("unknown compression %q", cfg.Compression)
+	}
+	loc := time.Local
+	if cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid rotation timezone %q: %w", cfg.Timezone, err)
+		}
+	}
+
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	dir, base := filepath.Split(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %q: %w", dir, err)
+	}
+	ext := filepath.Ext(base)
+
+	w := &RotatingWriter{
+		cfg:    cfg,
+		loc:    loc,
+		dir:    dir,
+		prefix: strings.TrimSuffix(base, ext),
+		ext:    ext,
+		now:    now,
+		mill:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if err := w.open(now()); err != nil {
+		return nil, err
+	}
+	if cfg.Interval == RotateNever {
+		return w, nil // nothing to compress or clean up
+	}
+	w.wg.Add(1)
+	go w.runMill()
+	w.triggerMill()
+	return w, nil
+}
+
+// Write appends p to the active file, rotating first when the current
+// period has ended or the file would exceed MaxSize. When rotation fails p
+// still goes to the current file and the error is returned; the next Write
+// tries to rotate again.
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	now := w.now()
+	var rotateErr error
+	switch {
+	case w.file == nil:
+		// Neither the next nor the current file could be opened last time.
+		if err := w.open(now); err != nil {
+			return 0, err
+		}
+	case !w.periodEnd.IsZero() && !now.Before(w.periodEnd):
+		rotateErr = w.rotate(now, false)
+	case w.maxSize() > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize():
+		rotateErr = w.rotate(now, true)
+	}
+	if w.file == nil {
+		return 0, rotateErr
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Sync commits the active file to stable storage.
+func (w *RotatingWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+// Close closes the active file and waits for pending compression and cleanup.
+func (w *RotatingWriter) Close() error {
+	w.once.Do(func() {
+		w.mu.Lock()
+		if w.file != nil {
+			w.closeErr = w.file.Close()
+			w.file = nil
+		}
+		w.closed = true
+		w.mu.Unlock()
+		close(w.done)
+		w.wg.Wait()
+	})
+	return w.closeErr
+}
+
+// Filename returns the path of the file currently written to.
+func (w *RotatingWriter) Filename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.name
+}
+
+func (w *RotatingWriter) maxSize() int64 {
+	if w.cfg.Interval == RotateNever {
+		return 0
+	}
+	return int64(w.cfg.MaxSize) * 1024 * 1024
+}
+
+// period returns the start and end of the rotation period containing t.
+func (w *RotatingWriter) period(t time.Time) (start, end time.Time) {
+	t = t.In(w.loc)
+	switch w.cfg.Interval {
+	case RotateDaily:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.loc)
+		return start, start.AddDate(0, 0, 1)
+	case RotateHourly:
+		start = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, w.loc)
+		return start, start.Add(time.Hour)
+	}
+	return time.Time{}, time.Time{}
+}
+
+// periodName returns the file name for the seq-th file of the period starting at start.
+func (w *RotatingWriter) periodName(start time.Time, seq int) string {
+	layout := dailyLayout
+	if w.cfg.Interval == RotateHourly {
+		layout = hourlyLayout
+	}
+	name := w.prefix + "-" + start.Format(layout)
+	if seq > 0 {
+		name += "." + strconv.Itoa(seq)
+	}
+	return filepath.Join(w.dir, name+w.ext)
+}
+
+// open opens the active file for t, continuing an existing file of the
+// current period when it still has room.
+func (w *RotatingWriter) open(t time.Time) error {
+	name := filepath.Join(w.dir, w.prefix+w.ext)
+	w.periodEnd = time.Time{}
+	if w.cfg.Interval == RotateDaily || w.cfg.Interval == RotateHourly {
+		var start time.Time
+		start, w.periodEnd = w.period(t)
+		// Continue the newest file of this period, so entries stay in
+		// order, unless it is full or already compressed.
+		for periodFileExists(w.periodName(start, w.seq+1)) {
+			w.seq++
+		}
+		name = w.periodName(start, w.seq)
+		if compressedExists(name) {
+			w.seq++
+		} else if info, err := os.Stat(name); err == nil && w.maxSize() > 0 && info.Size() >= w.maxSize() {
+			w.seq++
+		}
+		name = w.periodName(start, w.seq)
+	}
+
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %q: %w", name, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.name, w.size = f, name, info.Size()
+	return nil
+}
+
+// rotate closes the active file and opens the next one. bySize reports
+// whether the file is full rather than its period over. If the file cannot
+// be renamed or the next one opened, the current file is reopened so the
+// writer keeps logging, and the error is returned.
+func (w *RotatingWriter) rotate(now time.Time, bySize bool) error {
+	name, seq, periodEnd := w.name, w.seq, w.periodEnd
+	err := w.file.Close()
+	w.file = nil
+
+	if err == nil {
+		switch {
+		case w.cfg.Interval == "":
+			backup := filepath.Join(w.dir, w.prefix+"-"+now.In(w.loc).Format(backupLayout)+w.ext)
+			if err = os.Rename(w.name, backup); err != nil {
+				err = fmt.Errorf("failed to rotate log file: %w", err)
+			}
+		case bySize:
+			w.seq++
+		default:
+			w.seq = 0
+		}
+	}
+	if err == nil {
+		err = w.open(now)
+	}
+	if err != nil {
+		w.seq, w.periodEnd = seq, periodEnd
+		if rerr := w.reopen(name); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		return err
+	}
+	w.triggerMill()
+	return nil
+}
+
+// reopen continues writing to name after a failed rotation.
+func (w *RotatingWriter) reopen(name string) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen log file %q: %w", name, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.name, w.size = f, name, info.Size()
+	return nil
+}
+
+// periodFileExists reports whether name exists, compressed or not.
+func periodFileExists(name string) bool {
+	if _, err := os.Stat(name); err == nil {
+		return true
+	}
+	return compressedExists(name)
+}
+
+// compressedExists reports whether a compressed copy of name exists.
+func compressedExists(name string) bool {
+	for _, suffix := range []string{".gz", ".zst"} {
+		if _, err := os.Stat(name + suffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *RotatingWriter) triggerMill() {
+	select {
+	case w.mill <- struct{}{}:
+	default:
+	}
+}
+
+func (w *RotatingWriter) runMill() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.mill:
+			if err := w.millOnce(); err != nil {
+				fmt.Fprintf(os.Stderr, "[zlog] log rotation cleanup error: %v\n", err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// logFile is a rotated file belonging to the writer.
+type logFile struct {
+	path string
+	t    time.Time
+	seq  int
+	size int64
+}
+
+// millOnce compresses rotated files and applies the retention limits.
+func (w *RotatingWriter) millOnce() error {
+	files, active, err := w.listFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove []logFile
+	if w.cfg.MaxBackups > 0 && len(files) > w.cfg.MaxBackups {
+		remove = append(remove, files[w.cfg.MaxBackups:]...)
+		files = files[:w.cfg.MaxBackups]
+	}
+	if w.cfg.MaxAge > 0 {
+		cutoff := w.now().Add(-time.Duration(w.cfg.MaxAge) * 24 * time.Hour)
+		kept := files[:0]
+		for _, f := range files {
+			if f.t.Before(cutoff) {
+				remove = append(remove, f)
+			} else {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+	for _, f := range remove {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if w.cfg.Compression != "" {
+		for i, f := range files {
+			if strings.HasSuffix(f.path, w.ext) {
+				compressed, size, err := compressFile(f.path, w.cfg.Compression)
+				if err != nil {
+					return err
+				}
+				files[i].path, files[i].size = compressed, size
+			}
+		}
+	}
+
+	// Disk budget: drop the oldest files until everything fits.
+	if w.cfg.MaxTotalSize > 0 {
+		budget := int64(w.cfg.MaxTotalSize) * 1024 * 1024
+		total := active
+		for _, f := range files {
+			total += f.size
+		}
+		for i := len(files) - 1; i >= 0 && total > budget; i-- {
+			if err := os.Remove(files[i].path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			total -= files[i].size
+		}
+	}
+	return nil
+}
+
+// listFiles returns the rotated files, newest first, and the size of the active file.
+func (w *RotatingWriter) listFiles() ([]logFile, int64, error) {
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	current := filepath.Base(w.Filename())
+
+	var (
+		files  []logFile
+		active int64
+	)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		t, seq, ok := w.parseName(e.Name())
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if e.Name() == current {
+			active = info.Size()
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(w.dir, e.Name()), t: t, seq: seq, size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].t.Equal(files[j].t) {
+			return files[i].t.After(files[j].t)
+		}
+		return files[i].seq > files[j].seq
+	})
+	return files, active, nil
+}
+
+// parseName recognizes file names produced by the writer, such as
+// app-2026-10-17.2.log.gz, and returns their time stamp and sequence number.
+func (w *RotatingWriter) parseName(name string) (time.Time, int, bool) {
+	for _, suffix := range []string{".gz", ".zst"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if !strings.HasPrefix(name, w.prefix+"-") || !strings.HasSuffix(name, w.ext) {
+		return time.Time{}, 0, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, w.prefix+"-"), w.ext)
+
+	seq := 0
+	if i := strings.LastIndexByte(stamp, '.'); i >= 0 {
+		if n, err := strconv.Atoi(stamp[i+1:]); err == nil && !strings.Contains(stamp, "T") {
+			stamp, seq = stamp[:i], n
+		}
+	}
+	for _, layout := range []string{backupLayout, hourlyLayout, dailyLayout} {
+		if len(stamp) != len(layout) {
+			continue
+		}
+		if t, err := time.ParseInLocation(layout, stamp, w.loc); err == nil {
+			return t, seq, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// compressFile compresses path with the given algorithm, removes the
+// original and returns the new path and size.
+func compressFile(path, algorithm string) (string, int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+
+	target := path + ".gz"
+	if algorithm == CompressZstd {
+		target = path + ".zst"
+	}
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return "", 0, err
+	}
+
+	var zw io.WriteCloser
+	if algorithm == CompressZstd {
+		if zw, err = zstd.NewWriter(dst); err != nil {
+			dst.Close()
+			os.Remove(target)
+			return "", 0, err
+		}
+	} else {
+		zw = gzip.NewWriter(dst)
+	}
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(target)
+		return "", 0, fmt.Errorf("failed to compress %q: %w", path, err)
+	}
+
+	out, err := os.Stat(target)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", 0, err
+	}
+	return target, out.Size(), nil
+}
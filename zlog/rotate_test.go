@@ -0,0 +1,359 @@
+package zlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// testClock is a clock for RotatingWriter that tests move by hand.
+type testClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func newTestClock(t time.Time) *testClock { return &testClock{t: t} }
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+func shanghai(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	return loc
+}
+
+func newTestRotatingWriter(t *testing.T, path string, cfg RotationConfig, clock *testClock) *RotatingWriter {
+	t.Helper()
+	w, err := newRotatingWriter(path, cfg, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func writeString(t *testing.T, w io.Writer, s string) {
+	t.Helper()
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// dirFiles returns the sorted names of the files in dir.
+func dirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// waitFiles waits for the background cleanup to leave exactly want in dir.
+func waitFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	var got []string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if got = dirFiles(t, dir); strings.Join(got, ",") == strings.Join(want, ",") {
+			return
+		}
+	}
+	t.Fatalf("files = %v, want %v", got, want)
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingWriterNever(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	w, err := NewRotatingWriter(path, RotationConfig{Interval: RotateNever, MaxSize: 1, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 1500; i++ { // about 1.5MB, past MaxSize
+		if _, err := w.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Filename() != path {
+		t.Errorf("writing to %s, want %s", w.Filename(), path)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want only app.log", len(entries))
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 1500*1024 {
+		t.Errorf("app.log: %v, %v", info, err)
+	}
+}
+
+func TestRotatingWriterPeriods(t *testing.T) {
+	loc := shanghai(t)
+	tests := []struct {
+		interval     string
+		before, next time.Time
+		first, then  string
+	}{
+		{
+			// 16:00 UTC is already the next day in Shanghai.
+			RotateDaily,
+			time.Date(2026, 10, 17, 23, 59, 59, 0, loc), time.Date(2026, 10, 18, 0, 0, 0, 0, loc),
+			"app-2026-10-17.log", "app-2026-10-18.log",
+		},
+		{
+			RotateHourly,
+			time.Date(2026, 10, 17, 14, 59, 59, 0, loc), time.Date(2026, 10, 17, 15, 0, 0, 0, loc),
+			"app-2026-10-17-14.log", "app-2026-10-17-15.log",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := newTestClock(tt.before)
+			w := newTestRotatingWriter(t, filepath.Join(dir, "app.log"), RotationConfig{Interval: tt.interval, Timezone: "Asia/Shanghai"}, clock)
+			writeString(t, w, "before\n")
+			clock.Set(tt.before.Add(500 * time.Millisecond))
+			writeString(t, w, "still before\n")
+			clock.Set(tt.next)
+			writeString(t, w, "after\n")
+
+			if got := filepath.Base(w.Filename()); got != tt.then {
+				t.Errorf("active file = %s, want %s", got, tt.then)
+			}
+			if got := readFile(t, filepath.Join(dir, tt.first)); got != "before\nstill before\n" {
+				t.Errorf("%s = %q", tt.first, got)
+			}
+			if got := readFile(t, filepath.Join(dir, tt.then)); got != "after\n" {
+				t.Errorf("%s = %q", tt.then, got)
+			}
+		})
+	}
+}
+
+func TestRotatingWriterSizeSequence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	day := time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local)
+	cfg := RotationConfig{Interval: RotateDaily, MaxSize: 1}
+	w := newTestRotatingWriter(t, path, cfg, newTestClock(day))
+
+	chunk := strings.Repeat("x", 600*1024)
+	for i := 0; i < 3; i++ { // each chunk overflows the 1MB file started by the previous one
+		writeString(t, w, chunk)
+	}
+	waitFiles(t, dir, "app-2026-10-17.log", "app-2026-10-17.1.log", "app-2026-10-17.2.log")
+	if got := filepath.Base(w.Filename()); got != "app-2026-10-17.2.log" {
+		t.Errorf("active file = %s", got)
+	}
+	w.Close()
+
+	// A restarted writer continues the last file of the period that has room.
+	w = newTestRotatingWriter(t, path, cfg, newTestClock(day.Add(time.Hour)))
+	if got := filepath.Base(w.Filename()); got != "app-2026-10-17.2.log" {
+		t.Errorf("restarted writer uses %s", got)
+	}
+	writeString(t, w, chunk)
+	if got := filepath.Base(w.Filename()); got != "app-2026-10-17.3.log" {
+		t.Errorf("after overflow writer uses %s", got)
+	}
+
+	// The next day starts again without a sequence number.
+	clock := newTestClock(day)
+	w2 := newTestRotatingWriter(t, filepath.Join(dir, "next.log"), cfg, clock)
+	writeString(t, w2, chunk)
+	writeString(t, w2, chunk)
+	clock.Set(day.AddDate(0, 0, 1))
+	writeString(t, w2, "x")
+	if got := filepath.Base(w2.Filename()); got != "next-2026-10-18.log" {
+		t.Errorf("next day writer uses %s", got)
+	}
+}
+
+// createLogFiles creates files of size bytes in dir.
+func createLogFiles(t *testing.T, dir string, size int, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRotatingWriterRetention(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local)
+	old := []string{"app-2026-10-16.log", "app-2026-10-15.1.log", "app-2026-10-15.log", "app-2026-10-13.log", "app-2026-10-10.log"}
+	tests := []struct {
+		name string
+		cfg  RotationConfig
+		size int
+		want []string
+	}{
+		{
+			"max backups", RotationConfig{MaxBackups: 2}, 10,
+			[]string{"app-2026-10-16.log", "app-2026-10-15.1.log"},
+		},
+		{
+			// The cutoff is 2026-10-14 12:00.
+			"max age", RotationConfig{MaxAge: 3}, 10,
+			[]string{"app-2026-10-16.log", "app-2026-10-15.1.log", "app-2026-10-15.log"},
+		},
+		{
+			"max total size", RotationConfig{MaxTotalSize: 1}, 400 * 1024,
+			[]string{"app-2026-10-16.log", "app-2026-10-15.1.log"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			createLogFiles(t, dir, tt.size, append(old, "other.log", "app.txt")...)
+			tt.cfg.Interval = RotateDaily
+			newTestRotatingWriter(t, filepath.Join(dir, "app.log"), tt.cfg, newTestClock(now))
+			waitFiles(t, dir, append(tt.want, "app-2026-10-17.log", "other.log", "app.txt")...)
+		})
+	}
+}
+
+func TestRotatingWriterCompression(t *testing.T) {
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		CompressGzip: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		CompressZstd: func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) },
+	}
+	suffixes := map[string]string{CompressGzip: ".gz", CompressZstd: ".zst"}
+	for algorithm, newReader := range readers {
+		t.Run(algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			clock := newTestClock(time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local))
+			w := newTestRotatingWriter(t, filepath.Join(dir, "app.log"), RotationConfig{Interval: RotateDaily, Compression: algorithm}, clock)
+			writeString(t, w, "first day\n")
+			clock.Set(time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local))
+			writeString(t, w, "second day\n")
+
+			compressed := "app-2026-10-17.log" + suffixes[algorithm]
+			waitFiles(t, dir, compressed, "app-2026-10-18.log")
+			f, err := os.Open(filepath.Join(dir, compressed))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			r, err := newReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, err := io.ReadAll(r); err != nil || string(data) != "first day\n" {
+				t.Errorf("decompressed %q, %v", data, err)
+			}
+		})
+	}
+}
+
+func TestRotatingWriterRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local)
+	clock := newTestClock(now)
+	w := newTestRotatingWriter(t, path, RotationConfig{MaxSize: 1}, clock)
+
+	// A non-empty directory under the backup name makes the rename fail.
+	blocked := filepath.Join(dir, "app-"+now.Format(backupLayout)+".log")
+	if err := os.MkdirAll(filepath.Join(blocked, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	chunk := strings.Repeat("x", 600*1024)
+	writeString(t, w, chunk)
+	if n, err := io.WriteString(w, chunk); err == nil || n != len(chunk) {
+		t.Fatalf("write during failed rotation = %d, %v; want the data written and the error", n, err)
+	}
+	if n, err := io.WriteString(w, "retry\n"); err == nil || n != 6 {
+		t.Errorf("next write = %d, %v; want another rotation attempt", n, err)
+	}
+	if got := len(readFile(t, path)); got != 2*len(chunk)+6 {
+		t.Errorf("app.log has %d bytes, want every write", got)
+	}
+
+	clock.Set(now.Add(time.Second))
+	writeString(t, w, "rotated\n")
+	if got := readFile(t, path); got != "rotated\n" {
+		t.Errorf("app.log after rotation = %q", got)
+	}
+	backup := filepath.Join(dir, "app-"+now.Add(time.Second).Format(backupLayout)+".log")
+	if info, err := os.Stat(backup); err != nil || info.Size() != int64(2*len(chunk)+6) {
+		t.Errorf("backup: %v, %v", info, err)
+	}
+}
+
+func TestRotatingWriterOpenFailure(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 10, 17, 23, 0, 0, 0, time.Local)
+	clock := newTestClock(day)
+	w := newTestRotatingWriter(t, filepath.Join(dir, "app.log"), RotationConfig{Interval: RotateDaily}, clock)
+	writeString(t, w, "first\n")
+
+	// A directory under the next file name cannot be opened for writing.
+	next := filepath.Join(dir, "app-2026-10-18.log")
+	if err := os.Mkdir(next, 0755); err != nil {
+		t.Fatal(err)
+	}
+	clock.Set(day.Add(2 * time.Hour))
+	if _, err := io.WriteString(w, "kept\n"); err == nil {
+		t.Error("failed rotation not reported")
+	}
+	if got := filepath.Base(w.Filename()); got != "app-2026-10-17.log" {
+		t.Errorf("writing to %s after failed rotation", got)
+	}
+
+	if err := os.Remove(next); err != nil {
+		t.Fatal(err)
+	}
+	writeString(t, w, "second\n")
+	if got := readFile(t, filepath.Join(dir, "app-2026-10-17.log")); got != "first\nkept\n" {
+		t.Errorf("first day = %q", got)
+	}
+	if got := readFile(t, next); got != "second\n" {
+		t.Errorf("second day = %q", got)
+	}
+
+	w.Close()
+	if _, err := w.Write([]byte("x")); err != os.ErrClosed {
+		t.Errorf("write after Close = %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package zlog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SamplingConfig configures log sampling and rate limiting.
+//
+// Within each Tick the first First entries with the same level and message
+// are logged, then every Thereafter-th one. RateLimits additionally caps
+// chosen messages to one entry per interval regardless of level.
+type SamplingConfig struct {
+	Tick       time.Duration `yaml:"tick"`       // default 1s
+	First      int           `yaml:"first"`      // default 100
+	Thereafter int           `yaml:"thereafter"` // default 100; negative drops everything after First
+
+	// Levels overrides First/Thereafter per level, e.g. never sample errors.
+	Levels map[Level]LevelSampling `yaml:"levels"`
+
+	// RateLimits maps a message to the minimum interval between two logged
+	// entries with that message ("log this at most once per minute").
+	RateLimits map[string]time.Duration `yaml:"rate_limits"`
+
+	// SummaryInterval, when set, periodically logs "N similar messages
+	// suppressed" for every message that lost entries in the interval.
+	SummaryInterval time.Duration `yaml:"summary_interval"`
+
+	// OnDrop is called for every entry dropped by sampling or rate limiting.
+	OnDrop func(level Level, msg string) `yaml:"-"`
+}
+
+// LevelSampling overrides sampling for one level. Zero First or Thereafter
+// inherit the values of SamplingConfig.
+type LevelSampling struct {
+	Disable    bool `yaml:"disable"` // log every entry of this level
+	First      int  `yaml:"first"`
+	Thereafter int  `yaml:"thereafter"`
+}
+
+// SamplingStats reports counters of the sampler.
+type SamplingStats struct {
+	Dropped     uint64 // entries dropped by First/Thereafter sampling
+	RateLimited uint64 // entries dropped by RateLimits
+}
+
+// samplingKey identifies a group of similar entries for summaries.
+type samplingKey struct {
+	level zapcore.Level
+	msg   string
+}
+
+// sampler holds the state shared by a samplingCore and the cores derived from it.
+type sampler struct {
+	cfg  SamplingConfig
+	base zapcore.Core // unsampled core used for summaries, with the logger's static fields
+
+	mu         sync.Mutex
+	lastLogged map[string]time.Time // rate-limited message → last logged time
+	suppressed map[samplingKey]uint64
+
+	dropped     atomic.Uint64
+	rateLimited atomic.Uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// samplingCore samples entries per level and rate-limits selected messages.
+type samplingCore struct {
+	zapcore.Core
+	s      *sampler
+	levels map[zapcore.Level]zapcore.Core // per-level zap samplers
+}
+
+// newSamplingCore wraps core. static are the fields the logger adds to every
+// entry (LoggerConfig.Fields); summaries are written below the logger, so
+// they add them themselves.
+func newSamplingCore(core zapcore.Core, cfg SamplingConfig, static []Field) *samplingCore {
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Second
+	}
+	if cfg.First <= 0 {
+		cfg.First = 100
+	}
+	if cfg.Thereafter == 0 {
+		cfg.Thereafter = 100
+	}
+	s := &sampler{
+		cfg:        cfg,
+		base:       core.With(static),
+		lastLogged: make(map[string]time.Time),
+		suppressed: make(map[samplingKey]uint64),
+		done:       make(chan struct{}),
+	}
+
+	hook := zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped != 0 {
+			s.dropped.Add(1)
+			s.drop(ent)
+		}
+	})
+	c := &samplingCore{Core: core, s: s, levels: make(map[zapcore.Level]zapcore.Core)}
+	for lvl := zapcore.DebugLevel; lvl <= zapcore.FatalLevel; lvl++ {
+		first, thereafter := cfg.First, cfg.Thereafter
+		if ls, ok := cfg.Levels[fromZapCoreLevel(lvl)]; ok {
+			if ls.Disable {
+				continue
+			}
+			if ls.First > 0 {
+				first = ls.First
+			}
+			if ls.Thereafter != 0 {
+				thereafter = ls.Thereafter
+			}
+		}
+		if thereafter < 0 {
+			thereafter = 0 // zap drops every entry after first
+		}
+		c.levels[lvl] = zapcore.NewSamplerWithOptions(core, cfg.Tick, first, thereafter, hook)
+	}
+
+	if cfg.SummaryInterval > 0 {
+		s.wg.Add(1)
+		go s.run()
+	}
+	return c
+}
+
+func (c *samplingCore) With(fields []Field) zapcore.Core {
+	clone := &samplingCore{Core: c.Core.With(fields), s: c.s, levels: make(map[zapcore.Level]zapcore.Core, len(c.levels))}
+	for lvl, core := range c.levels {
+		clone.levels[lvl] = core.With(fields)
+	}
+	return clone
+}
+
+func (c *samplingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !c.s.allow(ent) {
+		return ce
+	}
+	if core, ok := c.levels[ent.Level]; ok {
+		return core.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// allow applies RateLimits to ent.
+func (s *sampler) allow(ent zapcore.Entry) bool {
+	interval, ok := s.cfg.RateLimits[ent.Message]
+	if !ok {
+		return true
+	}
+	s.mu.Lock()
+	last, seen := s.lastLogged[ent.Message]
+	if seen && ent.Time.Sub(last) < interval {
+		s.mu.Unlock()
+		s.rateLimited.Add(1)
+		s.drop(ent)
+		return false
+	}
+	s.lastLogged[ent.Message] = ent.Time
+	s.mu.Unlock()
+	return true
+}
+
+func (s *sampler) drop(ent zapcore.Entry) {
+	if s.cfg.SummaryInterval > 0 {
+		s.mu.Lock()
+		s.suppressed[samplingKey{ent.Level, ent.Message}]++
+		s.mu.Unlock()
+	}
+	if s.cfg.OnDrop != nil {
+		s.cfg.OnDrop(fromZapCoreLevel(ent.Level), ent.Message)
+	}
+}
+
+func (s *sampler) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.cfg.SummaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.summarize()
+		case <-s.done:
+			s.summarize()
+			return
+		}
+	}
+}
+
+// summarize logs one "N similar messages suppressed" entry per message that
+// lost entries since the last summary, bypassing sampling.
+func (s *sampler) summarize() {
+	s.mu.Lock()
+	counts := s.suppressed
+	s.suppressed = make(map[samplingKey]uint64)
+	s.mu.Unlock()
+
+	keys := make([]samplingKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return keys[i].level < keys[j].level
+		}
+		return keys[i].msg < keys[j].msg
+	})
+	for _, k := range keys {
+		ent := zapcore.Entry{
+			Level:   k.level,
+			Time:    time.Now(),
+			Message: fmt.Sprintf("%d similar messages suppressed", counts[k]),
+		}
+		if ce := s.base.Check(ent, nil); ce != nil {
+			ce.Write(String("message", k.msg), zap.Uint64("suppressed", counts[k]))
+		}
+	}
+}
+
+// Close stops the summary goroutine after logging a final summary.
+func (s *sampler) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+	return nil
+}
+
+func (s *sampler) stats() SamplingStats {
+	return SamplingStats{Dropped: s.dropped.Load(), RateLimited: s.rateLimited.Load()}
+}
+
+// SamplingStats returns the sampling counters of l; zero when sampling is off.
+func (l *Logger) SamplingStats() SamplingStats {
+	if l.parts.sampler == nil {
+		return SamplingStats{}
+	}
+	return l.parts.sampler.stats()
+}
+
+// GetSamplingStats returns the sampling counters of the default instance.
+func GetSamplingStats() SamplingStats {
+	return L().SamplingStats()
+}
@@ -0,0 +1,43 @@
+package zlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSamplingSummaryKeepsStaticFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l, err := NewWithCoreConfig(core, LoggerConfig{
+		Level:   InfoLevel,
+		Fields:  map[string]string{"service": "shop", "env": "prod"},
+		Sampler: &SamplingConfig{First: 1, Thereafter: -1, SummaryInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Info("cache miss")
+	}
+	_ = l.Close() // writes the final summary
+
+	var summary *observer.LoggedEntry
+	for _, e := range logs.All() {
+		if strings.HasSuffix(e.Message, "similar messages suppressed") {
+			summary = &e
+		}
+	}
+	if summary == nil {
+		t.Fatalf("no summary among %d entries", logs.Len())
+	}
+	fields := summary.ContextMap()
+	if fields["service"] != "shop" || fields["env"] != "prod" {
+		t.Errorf("summary lacks static fields: %v", fields)
+	}
+	if fields["message"] != "cache miss" || fields["suppressed"] != uint64(4) {
+		t.Errorf("unexpected summary fields: %v", fields)
+	}
+}
@@ -0,0 +1,886 @@
+package zlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// ShipConfig tunes batching, retries and spooling of the loki and otlp outputs.
+type ShipConfig struct {
+	BatchSize    int           `yaml:"batch_size"`     // entries per request, default 1000
+	BatchWait    time.Duration `yaml:"batch_wait"`     // longest delay before a partial batch is sent, default 1s
+	QueueSize    int           `yaml:"queue_size"`     // entries buffered in memory, default 10000; newer entries are dropped when full
+	Timeout      time.Duration `yaml:"timeout"`        // per request, default 10s
+	Gzip         bool          `yaml:"gzip"`           // gzip request bodies
+	MinBackoff   time.Duration `yaml:"min_backoff"`    // first retry delay, default 500ms
+	MaxBackoff   time.Duration `yaml:"max_backoff"`    // retry delay cap, default 1m
+	SpoolDir     string        `yaml:"spool_dir"`      // batches that could not be sent are kept here across restarts; empty keeps them in memory
+	SpoolMaxSize int           `yaml:"spool_max_size"` // MB, default 100; the oldest batches are discarded beyond it
+}
+
+func (c ShipConfig) withDefaults() ShipConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 1000
+	}
+	if c.BatchWait <= 0 {
+		c.BatchWait = time.Second
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 10000
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 500 * time.Millisecond
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = time.Minute
+		if c.MaxBackoff < c.MinBackoff {
+			c.MaxBackoff = c.MinBackoff
+		}
+	}
+	if c.SpoolMaxSize <= 0 {
+		c.SpoolMaxSize = 100
+	}
+	return c
+}
+
+// Default request paths used when OutputConfig.URL has none.
+const (
+	lokiPushPath = "/loki/api/v1/push"
+	otlpLogsPath = "/v1/logs"
+)
+
+// newShipOutput builds a core that batches entries and pushes them over
+// HTTP, either to a Loki push API or to an OTLP/HTTP logs endpoint (JSON
+// encoding). LoggerConfig.Fields and OutputConfig.Labels become Loki stream
+// labels or OTLP resource attributes.
+func newShipOutput(o OutputConfig, cfg LoggerConfig, encCfg zapcore.EncoderConfig,
+	enabler zapcore.LevelEnabler) (zapcore.Core, io.Closer, error) {
+
+	if o.URL == "" {
+		return nil, nil, fmt.Errorf("url is required for %s output", o.Type)
+	}
+	u, err := url.Parse(o.URL)
+	if err != nil || u.Host == "" {
+		return nil, nil, fmt.Errorf("invalid %s url %q", o.Type, o.URL)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = lokiPushPath
+		if o.Type == OutputOTLP {
+			u.Path = otlpLogsPath
+		}
+	}
+
+	labels := make(map[string]string, len(cfg.Fields)+len(o.Labels))
+	for k, v := range cfg.Fields {
+		labels[k] = v
+	}
+	for k, v := range o.Labels {
+		labels[k] = v
+	}
+	if o.Type == OutputLoki {
+		for k := range labels {
+			if lokiLabelName(k) == "level" {
+				return nil, nil, fmt.Errorf("loki label %q is reserved for the entry level", k)
+			}
+		}
+	}
+
+	core := &shipCore{LevelEnabler: enabler, otlp: o.Type == OutputOTLP}
+	var encode func([]shipRecord) ([]byte, error)
+	if core.otlp {
+		if _, ok := labels["service.name"]; !ok {
+			name := o.AppName
+			if name == "" {
+				name = filepath.Base(os.Args[0])
+			}
+			labels["service.name"] = name
+		}
+		core.resource = labels
+		encode = func(recs []shipRecord) ([]byte, error) { return encodeOTLP(labels, recs) }
+	} else {
+		format := o.Format
+		if format == "" {
+			format = FormatJSON
+		}
+		enc, err := newEncoder(format, encCfg, false, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		core.enc = enc
+		encode = func(recs []shipRecord) ([]byte, error) { return encodeLoki(labels, recs) }
+	}
+
+	ship := o.Ship
+	if ship == nil {
+		ship = &ShipConfig{}
+	}
+	s, err := newShipper(o.Type, u.String(), o.Headers, ship.withDefaults(), encode)
+	if err != nil {
+		return nil, nil, err
+	}
+	core.out = s
+	return core, s, nil
+}
+
+// shipRecord is one entry waiting to be shipped.
+type shipRecord struct {
+	time    time.Time
+	level   zapcore.Level
+	line    string                 // encoded entry, for loki
+	message string                 // for otlp
+	attrs   map[string]interface{} // fields, for otlp
+	traceID string
+	spanID  string
+}
+
+type shipCore struct {
+	zapcore.LevelEnabler
+	out      *shipper
+	otlp     bool
+	enc      zapcore.Encoder   // loki line encoder, with the fields added by With
+	fields   []Field           // otlp fields accumulated by With
+	resource map[string]string // otlp resource attributes, not repeated per record
+}
+
+func (c *shipCore) With(fields []Field) zapcore.Core {
+	clone := *c
+	if c.otlp {
+		clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+		return &clone
+	}
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *shipCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *shipCore) Write(ent zapcore.Entry, fields []Field) error {
+	rec := shipRecord{time: ent.Time, level: ent.Level}
+	if !c.otlp {
+		buf, err := c.enc.EncodeEntry(ent, fields)
+		if err != nil {
+			return err
+		}
+		rec.line = strings.TrimRight(buf.String(), "\n")
+		buf.Free()
+		c.out.add(rec)
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	attrs := enc.Fields
+	for k, v := range c.resource {
+		if s, ok := attrs[k].(string); ok && s == v {
+			delete(attrs, k)
+		}
+	}
+	rec.traceID, _ = attrs["trace_id"].(string)
+	rec.spanID, _ = attrs["span_id"].(string)
+	delete(attrs, "trace_id")
+	delete(attrs, "span_id")
+	if ent.LoggerName != "" {
+		attrs["logger.name"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		attrs["code.filepath"] = ent.Caller.File
+		attrs["code.lineno"] = int64(ent.Caller.Line)
+		if ent.Caller.Function != "" {
+			attrs["code.function"] = ent.Caller.Function
+		}
+	}
+	if ent.Stack != "" {
+		attrs["code.stacktrace"] = ent.Stack
+	}
+	rec.message = ent.Message
+	rec.attrs = attrs
+	c.out.add(rec)
+	return nil
+}
+
+func (c *shipCore) Sync() error {
+	return c.out.Sync()
+}
+
+// shipper batches records in one goroutine and sends them from another, so
+// a slow endpoint never holds up batching. A batch is handed to the sender
+// when it is idle and nothing is spooled; otherwise, and when a send fails
+// with a retryable error, it goes to the spool, which the sender drains in
+// order with exponential backoff.
+type shipper struct {
+	kind    string
+	url     string
+	headers map[string]string
+	cfg     ShipConfig
+	client  *http.Client
+	encode  func([]shipRecord) ([]byte, error)
+	spool   *spool
+
+	queue     chan shipRecord
+	flushReq  chan chan struct{}
+	batches   chan shipBatch
+	syncReq   chan chan error
+	wake      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	replayed  chan struct{}
+	closeOnce sync.Once
+
+	failing atomic.Bool   // an error has been reported and not yet recovered from
+	dropped atomic.Uint64 // records dropped because the queue was full
+}
+
+func newShipper(kind, url string, headers map[string]string, cfg ShipConfig,
+	encode func([]shipRecord) ([]byte, error)) (*shipper, error) {
+
+	sp, err := newSpool(cfg.SpoolDir, int64(cfg.SpoolMaxSize)*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+	s := &shipper{
+		kind:     kind,
+		url:      url,
+		headers:  headers,
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		encode:   encode,
+		spool:    sp,
+		queue:    make(chan shipRecord, cfg.QueueSize),
+		flushReq: make(chan chan struct{}),
+		batches:  make(chan shipBatch),
+		syncReq:  make(chan chan error),
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		replayed: make(chan struct{}),
+	}
+	go s.run()
+	go s.sender()
+	return s, nil
+}
+
+// add queues rec without blocking; it is dropped when the queue is full.
+func (s *shipper) add(rec shipRecord) {
+	select {
+	case <-s.done:
+		s.dropped.Add(1)
+		return
+	default:
+	}
+	select {
+	case s.queue <- rec:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Sync batches the queued records and waits for the sender to try them. It
+// returns an error when batches remain spooled for a later retry or a batch
+// was rejected since the previous Sync.
+func (s *shipper) Sync() error {
+	ack := make(chan struct{})
+	select {
+	case s.flushReq <- ack:
+		<-ack
+	case <-s.stopped:
+	}
+	result := make(chan error, 1)
+	select {
+	case s.syncReq <- result:
+		return <-result
+	case <-s.replayed:
+	}
+	if n := s.spool.len(); n > 0 {
+		return fmt.Errorf("%s output: %d batches not sent", s.kind, n)
+	}
+	return nil
+}
+
+// Close ships the queued records and makes one last attempt to send the
+// spool. Batches that still fail remain in the spool directory, if any.
+func (s *shipper) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		<-s.stopped
+		<-s.replayed
+		for {
+			b, ok := s.spool.peek()
+			if !ok {
+				break
+			}
+			if err := s.send(b.data); err != nil && retryable(err) {
+				break
+			}
+			s.spool.remove(b.id)
+		}
+		if n := s.spool.len(); n > 0 && s.spool.dir == "" {
+			fmt.Fprintf(os.Stderr, "[zlog] %s output: %d unsent batches discarded\n", s.kind, n)
+		}
+	})
+	return nil
+}
+
+func (s *shipper) run() {
+	defer close(s.stopped)
+	timer := time.NewTimer(s.cfg.BatchWait)
+	timer.Stop()
+	var batch []shipRecord
+
+	flush := func() {
+		timer.Stop()
+		if len(batch) > 0 {
+			s.ship(batch)
+			batch = nil
+		}
+		if n := s.dropped.Swap(0); n > 0 {
+			fmt.Fprintf(os.Stderr, "[zlog] %s output: queue full, %d entries dropped\n", s.kind, n)
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case rec := <-s.queue:
+				batch = append(batch, rec)
+				if len(batch) >= s.cfg.BatchSize {
+					flush()
+				}
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case rec := <-s.queue:
+			if len(batch) == 0 {
+				timer.Reset(s.cfg.BatchWait)
+			}
+			batch = append(batch, rec)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		case ack := <-s.flushReq:
+			drain()
+			close(ack)
+		case <-s.done:
+			drain()
+			return
+		}
+	}
+}
+
+// ship hands batch to the sender, or spools it when the sender is busy or
+// earlier batches are still pending.
+func (s *shipper) ship(batch []shipRecord) {
+	data, err := s.encode(batch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[zlog] %s output: encode batch: %v\n", s.kind, err)
+		return
+	}
+	b := shipBatch{id: s.spool.newID(), data: data}
+	if s.spool.len() == 0 {
+		select {
+		case s.batches <- b:
+			return
+		default:
+		}
+	}
+	s.spoolBatch(b)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *shipper) spoolBatch(b shipBatch) {
+	if err := s.spool.push(b); err != nil {
+		fmt.Fprintf(os.Stderr, "[zlog] %s output: spool batch: %v\n", s.kind, err)
+	}
+}
+
+// sender sends batches handed over by run and then the spool, oldest first,
+// backing off while the endpoint keeps failing. A batch whose send fails is
+// spooled under its original id, ahead of the batches that followed it.
+func (s *shipper) sender() {
+	defer close(s.replayed)
+	backoff := s.cfg.MinBackoff
+	var rejected error // reported by the next Sync
+	for {
+		b, spooled := s.spool.peek()
+		if !spooled {
+			select {
+			case b = <-s.batches:
+			case <-s.wake:
+				continue
+			case result := <-s.syncReq:
+				result <- rejected
+				rejected = nil
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		err := s.send(b.data)
+		if err == nil || !retryable(err) {
+			if err != nil {
+				s.report(err)
+				rejected = err
+			} else {
+				s.recovered()
+			}
+			if spooled {
+				s.spool.remove(b.id)
+			}
+			backoff = s.cfg.MinBackoff
+			continue
+		}
+		s.report(err)
+		if !spooled {
+			s.spoolBatch(b)
+		}
+
+		// Full jitter keeps many processes from retrying in lockstep.
+		timer := time.NewTimer(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+	wait:
+		for {
+			select {
+			case <-timer.C:
+				break wait
+			case result := <-s.syncReq:
+				result <- fmt.Errorf("%s output: %d batches spooled for retry: %w", s.kind, s.spool.len(), err)
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+		if backoff *= 2; backoff > s.cfg.MaxBackoff {
+			backoff = s.cfg.MaxBackoff
+		}
+	}
+}
+
+// shipError is a rejected request; only some statuses are worth retrying.
+type shipError struct {
+	status int
+	body   string
+}
+
+func (e *shipError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("server returned %d", e.status)
+	}
+	return fmt.Sprintf("server returned %d: %s", e.status, e.body)
+}
+
+// retryable reports whether err may succeed later: network errors, 408,
+// 429 and 5xx responses.
+func retryable(err error) bool {
+	var se *shipError
+	if !errors.As(err, &se) {
+		return true
+	}
+	return se.status == http.StatusRequestTimeout || se.status == http.StatusTooManyRequests || se.status >= 500
+}
+
+func (s *shipper) send(data []byte) error {
+	body := data
+	if s.cfg.Gzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return &shipError{status: resp.StatusCode, body: strings.TrimSpace(string(msg))}
+	}
+	return nil
+}
+
+// report prints the first error of an outage; later ones stay quiet until
+// a request succeeds again. Rejected batches are always reported.
+func (s *shipper) report(err error) {
+	if !retryable(err) {
+		fmt.Fprintf(os.Stderr, "[zlog] %s output: batch rejected: %v\n", s.kind, err)
+		return
+	}
+	if s.failing.CompareAndSwap(false, true) {
+		fmt.Fprintf(os.Stderr, "[zlog] %s output: %v; spooling and retrying\n", s.kind, err)
+	}
+}
+
+func (s *shipper) recovered() {
+	if s.failing.CompareAndSwap(true, false) {
+		fmt.Fprintf(os.Stderr, "[zlog] %s output: recovered\n", s.kind)
+	}
+}
+
+// spool is a queue of encoded batches ordered by id, kept in dir when set
+// (one file per batch, named by its id) or else in memory. Ids sort in the
+// order batches were created, including across restarts. Once its size
+// exceeds max the oldest batches are discarded.
+type spool struct {
+	dir string
+	max int64
+
+	mu    sync.Mutex
+	items []spoolItem
+	size  int64
+	seq   uint64
+}
+
+type spoolItem struct {
+	id   string
+	data []byte // memory only
+	size int64
+}
+
+// shipBatch is an encoded batch and the id it is spooled under.
+type shipBatch struct {
+	id   string
+	data []byte
+}
+
+const spoolExt = ".batch"
+
+func newSpool(dir string, max int64) (*spool, error) {
+	s := &spool{dir: dir, max: max}
+	if dir == "" {
+		return s, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory %q: %w", dir, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != spoolExt {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		s.items = append(s.items, spoolItem{id: e.Name(), size: info.Size()})
+		s.size += info.Size()
+	}
+	sort.Slice(s.items, func(i, j int) bool { return s.items[i].id < s.items[j].id })
+	return s, nil
+}
+
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+// newID returns the id of a new batch, greater than any issued before.
+func (s *spool) newID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	return fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, spoolExt)
+}
+
+// push adds b in id order.
+func (s *spool) push(b shipBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item := spoolItem{id: b.id, size: int64(len(b.data))}
+	if s.dir == "" {
+		item.data = b.data
+	} else {
+		tmp := filepath.Join(s.dir, item.id+".tmp")
+		if err := os.WriteFile(tmp, b.data, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(s.dir, item.id)); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	i := sort.Search(len(s.items), func(i int) bool { return s.items[i].id > item.id })
+	s.items = append(s.items, spoolItem{})
+	copy(s.items[i+1:], s.items[i:])
+	s.items[i] = item
+	s.size += item.size
+	for s.size > s.max && len(s.items) > 1 {
+		s.drop(0)
+	}
+	return nil
+}
+
+// peek returns the oldest batch. Unreadable spool files are discarded.
+func (s *spool) peek() (shipBatch, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.items) > 0 {
+		item := s.items[0]
+		if s.dir == "" {
+			return shipBatch{item.id, item.data}, true
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, item.id))
+		if err == nil {
+			return shipBatch{item.id, data}, true
+		}
+		s.drop(0)
+	}
+	return shipBatch{}, false
+}
+
+// remove deletes the batch id if it has not been discarded meanwhile.
+func (s *spool) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.items) > 0 && s.items[0].id == id {
+		s.drop(0)
+	}
+}
+
+func (s *spool) drop(i int) {
+	item := s.items[i]
+	if s.dir != "" {
+		os.Remove(filepath.Join(s.dir, item.id))
+	}
+	s.items = append(s.items[:i], s.items[i+1:]...)
+	s.size -= item.size
+}
+
+// encodeLoki builds a Loki push request with one stream per level.
+func encodeLoki(labels map[string]string, recs []shipRecord) ([]byte, error) {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	var streams []*stream
+	byLevel := make(map[zapcore.Level]*stream)
+	for _, r := range recs {
+		st := byLevel[r.level]
+		if st == nil {
+			st = &stream{Stream: make(map[string]string, len(labels)+1)}
+			for k, v := range labels {
+				st.Stream[lokiLabelName(k)] = v
+			}
+			st.Stream["level"] = r.level.String()
+			byLevel[r.level] = st
+			streams = append(streams, st)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(r.time.UnixNano(), 10), r.line})
+	}
+	return json.Marshal(struct {
+		Streams []*stream `json:"streams"`
+	}{streams})
+}
+
+// lokiLabelName converts key to a valid label name: [a-zA-Z_][a-zA-Z0-9_]*.
+func lokiLabelName(key string) string {
+	b := []byte(key)
+	for i, ch := range b {
+		if !(ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 {
+		return "_"
+	}
+	return string(b)
+}
+
+// encodeOTLP builds an OTLP/HTTP JSON ExportLogsServiceRequest.
+func encodeOTLP(resource map[string]string, recs []shipRecord) ([]byte, error) {
+	type record struct {
+		TimeUnixNano         string                   `json:"timeUnixNano"`
+		ObservedTimeUnixNano string                   `json:"observedTimeUnixNano"`
+		SeverityNumber       int                      `json:"severityNumber"`
+		SeverityText         string                   `json:"severityText"`
+		Body                 map[string]interface{}   `json:"body"`
+		Attributes           []map[string]interface{} `json:"attributes,omitempty"`
+		TraceID              string                   `json:"traceId,omitempty"`
+		SpanID               string                   `json:"spanId,omitempty"`
+	}
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	records := make([]record, 0, len(recs))
+	for _, r := range recs {
+		rec := record{
+			TimeUnixNano:         strconv.FormatInt(r.time.UnixNano(), 10),
+			ObservedTimeUnixNano: now,
+			SeverityNumber:       otlpSeverity(r.level),
+			SeverityText:         r.level.CapitalString(),
+			Body:                 otlpValue(r.message),
+			Attributes:           otlpAttributes(r.attrs),
+		}
+		if isHexID(r.traceID, 32) {
+			rec.TraceID = r.traceID
+		}
+		if isHexID(r.spanID, 16) {
+			rec.SpanID = r.spanID
+		}
+		records = append(records, rec)
+	}
+	res := make(map[string]interface{}, len(resource))
+	for k, v := range resource {
+		res[k] = v
+	}
+	return json.Marshal(map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": otlpAttributes(res)},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]string{"name": "github.com/chenzanhong/goutil/zlog"},
+				"logRecords": records,
+			}},
+		}},
+	})
+}
+
+// otlpSeverity maps levels to OTLP severity numbers.
+func otlpSeverity(l zapcore.Level) int {
+	switch l {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	case zapcore.PanicLevel:
+		return 21
+	default:
+		return 24
+	}
+}
+
+func otlpAttributes(m map[string]interface{}) []map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]map[string]interface{}, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, map[string]interface{}{"key": k, "value": otlpValue(m[k])})
+	}
+	return attrs
+}
+
+// otlpValue converts a field value into an OTLP AnyValue.
+func otlpValue(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case int32:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int16:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int8:
+		return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+	case uint64, uint32, uint16, uint8, uint, uintptr:
+		return map[string]interface{}{"intValue": fmt.Sprint(v)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case float32:
+		return map[string]interface{}{"doubleValue": float64(v)}
+	case time.Time:
+		return map[string]interface{}{"stringValue": v.Format(time.RFC3339Nano)}
+	case time.Duration:
+		return map[string]interface{}{"stringValue": v.String()}
+	case []byte:
+		return map[string]interface{}{"bytesValue": v}
+	case map[string]interface{}:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": otlpAttributes(v)}}
+	case []interface{}:
+		values := make([]map[string]interface{}, 0, len(v))
+		for _, e := range v {
+			values = append(values, otlpValue(e))
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case nil:
+		return map[string]interface{}{}
+	case error:
+		return map[string]interface{}{"stringValue": v.Error()}
+	case fmt.Stringer:
+		return map[string]interface{}{"stringValue": v.String()}
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return map[string]interface{}{"stringValue": string(b)}
+	}
+	return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+}
+
+func isHexID(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.Trim(s, "0") != ""
+}
@@ -0,0 +1,273 @@
+package zlog
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lokiReceiver is a Loki push endpoint recording the lines it accepts. Each
+// request is answered with the status returned by respond, 204 when nil.
+type lokiReceiver struct {
+	*httptest.Server
+	respond func() int
+
+	mu       sync.Mutex
+	requests int
+	gzipped  int
+	streams  []map[string]string
+	lines    []string
+}
+
+func newLokiReceiver(t *testing.T, respond func() int) *lokiReceiver {
+	r := &lokiReceiver{respond: respond}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *lokiReceiver) serve(w http.ResponseWriter, req *http.Request) {
+	if r.respond != nil {
+		if status := r.respond(); status != 0 && status/100 != 2 {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var body io.Reader = req.Body
+	gzipped := req.Header.Get("Content-Encoding") == "gzip"
+	if gzipped {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.NewDecoder(body).Decode(&push); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	if gzipped {
+		r.gzipped++
+	}
+	for _, st := range push.Streams {
+		r.streams = append(r.streams, st.Stream)
+		for _, v := range st.Values {
+			var line struct {
+				Msg string `json:"msg"`
+			}
+			_ = json.Unmarshal([]byte(v[1]), &line)
+			r.lines = append(r.lines, line.Msg)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *lokiReceiver) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+// waitLines waits until n lines have been received and returns them.
+func (r *lokiReceiver) waitLines(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lines := r.received()
+		if len(lines) >= n || time.Now().After(deadline) {
+			if len(lines) != n {
+				t.Fatalf("received %d lines, want %d: %v", len(lines), n, lines)
+			}
+			return lines
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newLokiLogger(t *testing.T, url string, ship ShipConfig) *Logger {
+	t.Helper()
+	l, err := New(LoggerConfig{
+		Level:   InfoLevel,
+		Fields:  map[string]string{"app": "shop"},
+		Outputs: []OutputConfig{{Type: OutputLoki, URL: url, Ship: &ship}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func msgs(prefix string, n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = prefix + string(rune('a'+i))
+	}
+	return out
+}
+
+func checkLines(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("lines = %v, want %v", got, want)
+	}
+}
+
+func TestShipBatchingAndGzip(t *testing.T) {
+	recv := newLokiReceiver(t, nil)
+	l := newLokiLogger(t, recv.URL, ShipConfig{BatchSize: 3, BatchWait: time.Hour, Gzip: true})
+	defer l.Close()
+
+	want := msgs("m", 7)
+	for _, m := range want {
+		l.Info(m)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	checkLines(t, recv.waitLines(t, 7), want)
+
+	recv.mu.Lock()
+	defer recv.mu.Unlock()
+	if recv.requests != 3 || recv.gzipped != 3 {
+		t.Errorf("got %d requests, %d gzipped, want 3 of each", recv.requests, recv.gzipped)
+	}
+	if st := recv.streams[0]; st["app"] != "shop" || st["level"] != "info" {
+		t.Errorf("stream labels = %v", st)
+	}
+}
+
+func TestShipRetryWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var attempts []time.Time
+	recv := newLokiReceiver(t, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, time.Now())
+		if len(attempts) <= 3 {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	})
+	l := newLokiLogger(t, recv.URL, ShipConfig{BatchWait: 10 * time.Millisecond, MinBackoff: 20 * time.Millisecond, MaxBackoff: time.Second})
+	defer l.Close()
+
+	l.Info("first")
+	if err := l.Sync(); err == nil {
+		t.Error("Sync returned nil while the batch was only spooled")
+	}
+	l.Info("second")
+	checkLines(t, recv.waitLines(t, 2), []string{"first", "second"})
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync after recovery: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The waits after the second and third failures are at least half of
+	// 40ms and 80ms.
+	if len(attempts) < 4 || attempts[3].Sub(attempts[1]) < 30*time.Millisecond {
+		t.Errorf("retries did not back off: %v", attempts)
+	}
+}
+
+func TestShipRejectedBatchReportedBySync(t *testing.T) {
+	recv := newLokiReceiver(t, func() int { return http.StatusBadRequest })
+	l := newLokiLogger(t, recv.URL, ShipConfig{BatchWait: time.Hour})
+	defer l.Close()
+
+	l.Info("rejected")
+	if err := l.Sync(); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Sync = %v, want the rejection", err)
+	}
+	if err := l.Sync(); err != nil {
+		t.Errorf("rejection reported twice: %v", err)
+	}
+}
+
+func TestShipSpoolReplayAfterRestart(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	recv := newLokiReceiver(t, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			return http.StatusBadGateway
+		}
+		return 0
+	})
+	dir := t.TempDir()
+	cfg := ShipConfig{BatchSize: 2, BatchWait: time.Hour, MinBackoff: 10 * time.Millisecond, SpoolDir: dir}
+
+	l := newLokiLogger(t, recv.URL, cfg)
+	want := msgs("m", 5)
+	for _, m := range want {
+		l.Info(m)
+	}
+	if err := l.Close(); err == nil {
+		t.Error("Close returned nil with batches left in the spool")
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 3 {
+		t.Fatalf("spool holds %d files, want 3", len(files))
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	l = newLokiLogger(t, recv.URL, cfg)
+	l.Info("after restart")
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync after restart: %v", err)
+	}
+	checkLines(t, recv.waitLines(t, 6), append(want, "after restart"))
+	if err := l.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spool not emptied: %d files left", len(files))
+	}
+}
+
+func TestShipHangingEndpointDoesNotDrop(t *testing.T) {
+	release := make(chan struct{})
+	recv := newLokiReceiver(t, func() int {
+		<-release
+		return 0
+	})
+	l := newLokiLogger(t, recv.URL, ShipConfig{BatchSize: 1, QueueSize: 5, Timeout: 10 * time.Second})
+
+	want := msgs("m", 20)
+	for _, m := range want {
+		l.Info(m)
+		time.Sleep(time.Millisecond) // let the batcher keep up with the small queue
+	}
+	close(release)
+	checkLines(t, recv.waitLines(t, 20), want)
+	_ = l.Close()
+}
+
+func TestShipLokiLevelLabelReserved(t *testing.T) {
+	_, err := New(LoggerConfig{
+		Level:   InfoLevel,
+		Outputs: []OutputConfig{{Type: OutputLoki, URL: "http://127.0.0.1:1", Labels: map[string]string{"level": "x"}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("New = %v, want the reserved label error", err)
+	}
+}
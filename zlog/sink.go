@@ -0,0 +1,429 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Output types for OutputConfig.Type.
+const (
+	OutputStdout   = "stdout"
+	OutputStderr   = "stderr"
+	OutputFile     = "file"
+	OutputSyslog   = "syslog"
+	OutputJournald = "journald"
+	OutputTCP      = "tcp"
+	OutputUDP      = "udp"
+	OutputUnix     = "unix"     // unix stream socket
+	OutputUnixgram = "unixgram" // unix datagram socket
+	OutputLoki     = "loki"     // Loki push API over HTTP
+	OutputOTLP     = "otlp"     // OTLP/HTTP logs endpoint, JSON encoding
+)
+
+// Encoder formats for OutputConfig.Format.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+	FormatLogfmt  = "logfmt"
+)
+
+// OutputConfig describes one log sink. When LoggerConfig.Outputs is set it
+// replaces Output, Format and FilePath.
+type OutputConfig struct {
+	Type     string `yaml:"type"`      // stdout、stderr、file、syslog、journald、tcp、udp、unix、unixgram、loki、otlp
+	Level    Level  `yaml:"level"`     // lowest level written; empty means LoggerConfig.Level
+	MaxLevel Level  `yaml:"max_level"` // highest level written; empty means no upper bound
+	Format   string `yaml:"format"`    // json、console、logfmt; default json, console for stdout/stderr
+	Path     string `yaml:"path"`      // file path, for type file
+	Address  string `yaml:"address"`   // host:port or socket path, for network, syslog and journald types
+
+	// Syslog and journald settings.
+	Protocol string `yaml:"protocol"` // rfc3164 (default) or rfc5424, for type syslog
+	Facility string `yaml:"facility"` // user (default), daemon, local0…local7, …
+	AppName  string `yaml:"app_name"` // APP-NAME / SYSLOG_IDENTIFIER; default program name
+
+	// Rotation settings for type file; zero values inherit from LoggerConfig.
+	MaxSize    int   `yaml:"max_size"`
+	MaxBackups int   `yaml:"max_backups"`
+	MaxAge     int   `yaml:"max_age"`
+	Compress   *bool `yaml:"compress"`
+
+	// Time-based rotation and retention for type file; nil uses LoggerConfig.Rotation.
+	Rotation *RotationConfig `yaml:"rotation"`
+
+	// Key names, time format, layout and color for this output; nil uses LoggerConfig.Encoding.
+	Encoding *EncodingConfig `yaml:"encoding"`
+
+	// Remote shipping settings, for types loki and otlp.
+	URL     string            `yaml:"url"`     // push endpoint; the default path is added when missing
+	Headers map[string]string `yaml:"headers"` // e.g. Authorization or X-Scope-OrgID
+	Labels  map[string]string `yaml:"labels"`  // added to LoggerConfig.Fields as stream labels / resource attributes
+	Ship    *ShipConfig       `yaml:"ship"`    // batching, retries and spool; nil uses defaults
+}
+
+// legacyOutputs translates the single Output/Format/FilePath settings into sinks.
+func legacyOutputs(cfg LoggerConfig) []OutputConfig {
+	var outputs []OutputConfig
+	if cfg.Output == "console" || cfg.Output == "both" {
+		outputs = append(outputs, OutputConfig{Type: OutputStdout, Format: cfg.Format})
+	}
+	if cfg.Output == "file" || cfg.Output == "both" {
+		outputs = append(outputs, OutputConfig{Type: OutputFile, Format: cfg.Format, Path: cfg.FilePath})
+	}
+	return outputs
+}
+
+// levelRange enables levels in [min, max].
+func levelRange(min, max zapcore.Level) zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= min && l <= max
+	})
+}
+
+// newEncoder builds the encoder for format. color enables colored levels for
+// console output; a non-empty layout renders console output through
+// NewLayoutEncoder.
+func newEncoder(format string, encCfg zapcore.EncoderConfig, color bool, layout string) (zapcore.Encoder, error) {
+	switch format {
+	case FormatJSON:
+		return zapcore.NewJSONEncoder(encCfg), nil
+	case FormatConsole:
+		if layout != "" {
+			encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+			if color {
+				encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+			}
+			return NewLayoutEncoder(layout, encCfg)
+		}
+		if color {
+			encCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encCfg), nil
+	case FormatLogfmt:
+		return NewLogfmtEncoder(encCfg), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// buildOutput creates the core for one sink. The returned closer, if any,
+// releases the sink's file or connection.
+func buildOutput(o OutputConfig, cfg LoggerConfig,
+	wrap func(zapcore.WriteSyncer) zapcore.WriteSyncer) (zapcore.Core, io.Closer, error) {
+
+	encoding := cfg.Encoding
+	if o.Encoding != nil {
+		encoding = o.Encoding
+	}
+	encCfg, err := encoding.encoderConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	minLevel := cfg.Level
+	if o.Level != "" {
+		minLevel = o.Level
+	}
+	maxLevel := FatalLevel
+	if o.MaxLevel != "" {
+		maxLevel = o.MaxLevel
+	}
+	enabler := levelRange(minLevel.toZapCoreLevel(), maxLevel.toZapCoreLevel())
+
+	format := o.Format
+	if format == "" {
+		format = FormatJSON
+		if o.Type == OutputStdout || o.Type == OutputStderr {
+			format = FormatConsole
+		}
+	}
+
+	var (
+		ws     zapcore.WriteSyncer
+		closer io.Closer
+		color  bool
+	)
+	switch o.Type {
+	case OutputStdout:
+		ws, color = zapcore.Lock(os.Stdout), encoding.useColor(os.Stdout)
+	case OutputStderr:
+		ws, color = zapcore.Lock(os.Stderr), encoding.useColor(os.Stderr)
+	case OutputFile:
+		w, err := newFileWriter(o, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		ws, closer = zapcore.AddSync(w), w
+	case OutputTCP, OutputUDP, OutputUnix, OutputUnixgram:
+		if o.Address == "" {
+			return nil, nil, fmt.Errorf("address is required for %s output", o.Type)
+		}
+		w := newNetWriter(o.Type, o.Address)
+		ws, closer = w, w
+		if isDatagram(o.Type) {
+			// Each write is one datagram; buffering would merge entries.
+			wrap = noWrap
+		}
+	case OutputSyslog:
+		return newSyslogOutput(o, encCfg, enabler, wrap)
+	case OutputJournald:
+		return newJournaldOutput(o, enabler)
+	case OutputLoki, OutputOTLP:
+		// Shipping outputs batch and queue on their own.
+		return newShipOutput(o, cfg, encCfg, enabler)
+	default:
+		return nil, nil, fmt.Errorf("unknown output type %q", o.Type)
+	}
+
+	var layout string
+	if encoding != nil {
+		layout = encoding.Layout
+	}
+	enc, err := newEncoder(format, encCfg, color, layout)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, nil, err
+	}
+	return zapcore.NewCore(enc, wrap(ws), enabler), closer, nil
+}
+
+func noWrap(ws zapcore.WriteSyncer) zapcore.WriteSyncer { return ws }
+
+// newFileWriter creates a rotating file writer, resolving relative paths
+// and creating the parent directory. Size-based rotation uses lumberjack;
+// a RotationConfig on the output or LoggerConfig switches to RotatingWriter.
+func newFileWriter(o OutputConfig, cfg LoggerConfig) (io.WriteCloser, error) {
+	path := o.Path
+	if path == "" {
+		return nil, fmt.Errorf("path is required for file output")
+	}
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		path = filepath.Join(wd, path)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %q: %w", dir, err)
+	}
+
+	w := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    cfg.MaxSize,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAge,
+		Compress:   cfg.Compress,
+	}
+	if o.MaxSize > 0 {
+		w.MaxSize = o.MaxSize
+	}
+	if o.MaxBackups > 0 {
+		w.MaxBackups = o.MaxBackups
+	}
+	if o.MaxAge > 0 {
+		w.MaxAge = o.MaxAge
+	}
+	if o.Compress != nil {
+		w.Compress = *o.Compress
+	}
+
+	rotation := o.Rotation
+	if rotation == nil {
+		rotation = cfg.Rotation
+	}
+	if rotation == nil {
+		return w, nil
+	}
+	if rotation.Interval == RotateNever {
+		return NewRotatingWriter(path, RotationConfig{Interval: RotateNever})
+	}
+
+	// Unset rotation limits inherit the size-based settings.
+	rc := *rotation
+	if rc.MaxSize == 0 {
+		rc.MaxSize = w.MaxSize
+	}
+	if rc.MaxBackups == 0 {
+		rc.MaxBackups = w.MaxBackups
+	}
+	if rc.MaxAge == 0 {
+		rc.MaxAge = w.MaxAge
+	}
+	if rc.Compression == "" && w.Compress {
+		rc.Compression = CompressGzip
+	}
+	return NewRotatingWriter(path, rc)
+}
+
+// Reconnect backoff of netWriter, and how long entries logged right after
+// start wait for the first connection.
+const (
+	netMinBackoff  = 100 * time.Millisecond
+	netMaxBackoff  = 30 * time.Second
+	netStartupWait = time.Second
+)
+
+// netWriter writes each entry to a network or unix socket connection. It
+// dials in the background: while disconnected, writes fail fast instead of
+// blocking the logging call, and a goroutine redials with exponential backoff.
+// The first write while disconnected returns an error; later ones are
+// dropped and counted, and the count is reported once reconnected.
+type netWriter struct {
+	network string
+	addr    string
+	timeout time.Duration
+
+	ready     chan struct{} // closed after the first dial attempt or netStartupWait
+	readyOnce sync.Once
+	done      chan struct{} // closed by Close
+	wg        sync.WaitGroup
+
+	mu      sync.Mutex
+	conn    net.Conn
+	dialing bool
+	closed  bool
+	dropped int // entries lost since the connection went down
+}
+
+func newNetWriter(network, addr string) *netWriter {
+	w := &netWriter{
+		network: network,
+		addr:    addr,
+		timeout: 5 * time.Second,
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.mu.Lock()
+	w.startDial()
+	w.mu.Unlock()
+	time.AfterFunc(netStartupWait, w.setReady)
+	return w
+}
+
+func (w *netWriter) setReady() {
+	w.readyOnce.Do(func() { close(w.ready) })
+}
+
+// startDial starts the reconnect goroutine unless one is running; w.mu must be held.
+func (w *netWriter) startDial() {
+	if w.dialing || w.closed {
+		return
+	}
+	w.dialing = true
+	w.wg.Add(1)
+	go w.dial()
+}
+
+func (w *netWriter) dial() {
+	defer w.wg.Done()
+	backoff := netMinBackoff
+	for {
+		conn, err := net.DialTimeout(w.network, w.addr, w.timeout)
+		w.mu.Lock()
+		if w.closed {
+			w.dialing = false
+			w.mu.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			w.setReady()
+			return
+		}
+		if err == nil {
+			dropped := w.dropped
+			w.conn, w.dialing, w.dropped = conn, false, 0
+			w.mu.Unlock()
+			w.setReady()
+			if dropped > 0 {
+				fmt.Fprintf(os.Stderr, "[zlog] reconnected to %s %s, %d entries dropped while disconnected\n", w.network, w.addr, dropped)
+			}
+			return
+		}
+		w.mu.Unlock()
+		w.setReady()
+
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+		}
+		if backoff *= 2; backoff > netMaxBackoff {
+			backoff = netMaxBackoff
+		}
+	}
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	return w.send(len(p), func(conn net.Conn) (int, error) {
+		return conn.Write(p)
+	})
+}
+
+// send runs write on the current connection. A failed write closes the
+// connection and starts redialing, except for a datagram too large for the
+// socket, which says nothing about the connection.
+func (w *netWriter) send(size int, write func(net.Conn) (int, error)) (int, error) {
+	<-w.ready // entries logged right after start wait for the first dial
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, fmt.Errorf("%s %s: %w", w.network, w.addr, os.ErrClosed)
+	}
+	if w.conn == nil {
+		return w.drop(size)
+	}
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	n, err := write(w.conn)
+	if err != nil && !errors.Is(err, syscall.EMSGSIZE) {
+		_ = w.conn.Close()
+		w.conn = nil
+		w.startDial()
+		w.dropped++
+	}
+	return n, err
+}
+
+// drop accounts for an entry written while disconnected; w.mu must be held.
+func (w *netWriter) drop(n int) (int, error) {
+	w.dropped++
+	if w.dropped == 1 {
+		return 0, fmt.Errorf("%s %s is disconnected, dropping entries until reconnected", w.network, w.addr)
+	}
+	return n, nil
+}
+
+func (w *netWriter) Sync() error {
+	return nil
+}
+
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	var err error
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
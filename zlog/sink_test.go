@@ -0,0 +1,122 @@
+package zlog
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNetWriterReconnects checks that writes fail fast while the collector
+// is down and resume on a new connection once it is back.
+func TestNetWriterReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	lines := make(chan string, 100)
+	serve := func(ln net.Listener) {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for sc.Scan() {
+					lines <- sc.Text()
+				}
+			}()
+		}
+	}
+	go serve(ln)
+
+	w := newNetWriter("tcp", addr)
+	defer w.Close()
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	expectLine(t, lines, "first")
+
+	// Take the collector down: close the listener and the accepted connection.
+	ln.Close()
+	w.mu.Lock()
+	w.conn.Close() // the peer closing is only noticed on a later write; force it
+	w.mu.Unlock()
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		_, _ = w.Write([]byte("lost\n"))
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("writes while disconnected took %v", elapsed)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer ln.Close()
+	go serve(ln)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := w.Write([]byte("back\n")); err == nil {
+			w.mu.Lock()
+			connected := w.conn != nil
+			w.mu.Unlock()
+			if connected {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("writer did not reconnect")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	expectLine(t, lines, "back")
+}
+
+func expectLine(t *testing.T, lines <-chan string, want string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-lines:
+			if strings.TrimSpace(got) == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("did not receive %q", want)
+		}
+	}
+}
+
+// TestNetWriterUnreachable checks that an unreachable collector delays only
+// the entries logged right after start.
+func TestNetWriterUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := newNetWriter("tcp", addr)
+	defer w.Close()
+	start := time.Now()
+	if _, err := w.Write([]byte("a\n")); err == nil {
+		t.Error("first write while disconnected should report the outage")
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := w.Write([]byte("b\n")); err != nil {
+			t.Errorf("later writes should be dropped silently: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > netStartupWait+500*time.Millisecond {
+		t.Fatalf("writes took %v", elapsed)
+	}
+}
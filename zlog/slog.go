@@ -0,0 +1,329 @@
+package zlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Extra slog levels used for zlog's panic and fatal levels, which slog lacks.
+const (
+	SlogLevelPanic = slog.Level(12)
+	SlogLevelFatal = slog.Level(16)
+)
+
+// SlogLevel converts a zlog Level to the matching slog.Level.
+func (l Level) SlogLevel() slog.Level {
+	switch l {
+	case DebugLevel:
+		return slog.LevelDebug
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case PanicLevel:
+		return SlogLevelPanic
+	case FatalLevel:
+		return SlogLevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// LevelFromSlog maps a slog.Level to the nearest zlog Level.
+// Levels above error are kept at error so slog callers never trigger panic or exit.
+func LevelFromSlog(l slog.Level) Level {
+	switch {
+	case l < slog.LevelInfo:
+		return DebugLevel
+	case l < slog.LevelWarn:
+		return InfoLevel
+	case l < slog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+// ========== slog.Handler backed by zap ==========
+
+// SlogHandler is a slog.Handler that writes records to a zapcore.Core.
+type SlogHandler struct {
+	core   zapcore.Core
+	logger *Logger // runs hooks when set
+	name   string
+	// global resolves core, logger and name from the default instance on
+	// every record, so the handler follows ReplaceGlobals.
+	global bool
+	groups []string // groups opened by WithGroup and not yet materialized
+	// grouped holds attributes added inside a group, with the namespaces that
+	// open it, and every attribute of a global handler. They are written per record after the context fields, which
+	// must stay at the top level.
+	grouped []Field
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler returns a slog.Handler writing to the default zlog
+// instance. It follows ReplaceGlobals.
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{global: true}
+}
+
+// SlogHandler returns a slog.Handler writing to l, running its hooks. Records
+// carry the name of l, so its module level applies.
+func (l *Logger) SlogHandler() *SlogHandler {
+	return &SlogHandler{core: l.base.Core(), logger: l, name: l.base.Name()}
+}
+
+// NewSlogHandlerWithCore returns a slog.Handler writing to the given core.
+func NewSlogHandlerWithCore(core zapcore.Core) *SlogHandler {
+	return &SlogHandler{core: core}
+}
+
+// NewSlogLogger returns a *slog.Logger writing to the default zlog
+// instance. It follows ReplaceGlobals.
+func NewSlogLogger() *slog.Logger {
+	return slog.New(NewSlogHandler())
+}
+
+// SetDefault installs a zlog-backed handler as slog.Default(),
+// which also redirects the standard log package. The handler follows
+// ReplaceGlobals, so InitLogger may run before or after it.
+func SetDefault() {
+	slog.SetDefault(NewSlogLogger())
+}
+
+// current returns the handler's core, hooks and logger name, taken from
+// the default instance for handlers that follow ReplaceGlobals.
+func (h *SlogHandler) current() (zapcore.Core, *Logger, string) {
+	if h.global {
+		l := L()
+		return l.base.Core(), l, l.base.Name()
+	}
+	return h.core, h.logger, h.name
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	core, _, _ := h.current()
+	return core.Enabled(LevelFromSlog(level).toZapCoreLevel())
+}
+
+// Handle implements slog.Handler.
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	core, logger, name := h.current()
+	level := LevelFromSlog(record.Level)
+	ent := zapcore.Entry{
+		Level:      level.toZapCoreLevel(),
+		Time:       record.Time,
+		Message:    record.Message,
+		LoggerName: name,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, frame.PC != 0)
+	}
+
+	ce := core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := contextFields(ctx)
+	fields = append(fields, h.grouped...)
+	if record.NumAttrs() > 0 {
+		for _, g := range h.groups {
+			fields = append(fields, zap.Namespace(g))
+		}
+		record.Attrs(func(a slog.Attr) bool {
+			if f, ok := attrToField(a); ok {
+				fields = append(fields, f)
+			}
+			return true
+		})
+	}
+
+	if logger != nil {
+		logger.executeHooks(level, record.Message, fields)
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]Field, 0, len(attrs))
+	for _, a := range attrs {
+		if f, ok := attrToField(a); ok {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return h
+	}
+	// A handler following ReplaceGlobals has no core of its own to bind the
+	// fields to, so it writes them per record like grouped attributes.
+	if len(h.groups) == 0 && len(h.grouped) == 0 && !h.global {
+		return &SlogHandler{core: h.core.With(fields), logger: h.logger, name: h.name}
+	}
+	grouped := slices.Clip(h.grouped)
+	for _, g := range h.groups {
+		grouped = append(grouped, zap.Namespace(g))
+	}
+	return &SlogHandler{core: h.core, logger: h.logger, name: h.name, global: h.global, grouped: append(grouped, fields...)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{core: h.core, logger: h.logger, name: h.name, global: h.global, groups: append(slices.Clip(h.groups), name), grouped: h.grouped}
+}
+
+// attrToField converts a slog.Attr to a zap field, dropping empty attributes as slog requires.
+func attrToField(a slog.Attr) (Field, bool) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return Field{}, false
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return zap.String(a.Key, a.Value.String()), true
+	case slog.KindInt64:
+		return zap.Int64(a.Key, a.Value.Int64()), true
+	case slog.KindUint64:
+		return zap.Uint64(a.Key, a.Value.Uint64()), true
+	case slog.KindFloat64:
+		return zap.Float64(a.Key, a.Value.Float64()), true
+	case slog.KindBool:
+		return zap.Bool(a.Key, a.Value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(a.Key, a.Value.Duration()), true
+	case slog.KindTime:
+		return zap.Time(a.Key, a.Value.Time()), true
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return Field{}, false
+		}
+		if a.Key == "" {
+			// Inline groups are flattened into the parent.
+			return zap.Inline(groupMarshaler(attrs)), true
+		}
+		return zap.Object(a.Key, groupMarshaler(attrs)), true
+	default:
+		if err, ok := a.Value.Any().(error); ok {
+			return zap.NamedError(a.Key, err), true
+		}
+		return zap.Any(a.Key, a.Value.Any()), true
+	}
+}
+
+type groupMarshaler []slog.Attr
+
+func (attrs groupMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, a := range attrs {
+		if f, ok := attrToField(a); ok {
+			f.AddTo(enc)
+		}
+	}
+	return nil
+}
+
+// ========== zapcore.Core backed by slog ==========
+
+// slogCore is a zapcore.Core that forwards entries to a slog.Handler.
+type slogCore struct {
+	handler slog.Handler
+}
+
+// NewSlogCore returns a zapcore.Core that forwards entries to handler.
+func NewSlogCore(handler slog.Handler) zapcore.Core {
+	return &slogCore{handler: handler}
+}
+
+// NewFromSlog returns a Logger that writes through logger's handler.
+func NewFromSlog(logger *slog.Logger) *Logger {
+	return NewWithCore(NewSlogCore(logger.Handler()))
+}
+
+// InitWithSlog installs a default instance with logger as its backend,
+// so zlog.Info and friends are written through the slog handler.
+func InitWithSlog(logger *slog.Logger) error {
+	if logger == nil {
+		return fmt.Errorf("slog logger is nil")
+	}
+	ReplaceGlobals(NewFromSlog(logger))
+	return nil
+}
+
+func (c *slogCore) Enabled(lvl zapcore.Level) bool {
+	return c.handler.Enabled(context.Background(), fromZapCoreLevel(lvl).SlogLevel())
+}
+
+func (c *slogCore) With(fields []Field) zapcore.Core {
+	return &slogCore{handler: c.handler.WithAttrs(fieldsToAttrs(fields))}
+}
+
+func (c *slogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *slogCore) Write(ent zapcore.Entry, fields []Field) error {
+	t := ent.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	record := slog.NewRecord(t, fromZapCoreLevel(ent.Level).SlogLevel(), ent.Message, ent.Caller.PC)
+	if ent.LoggerName != "" {
+		record.AddAttrs(slog.String("logger", ent.LoggerName))
+	}
+	record.AddAttrs(fieldsToAttrs(fields)...)
+	if ent.Stack != "" {
+		record.AddAttrs(slog.String("stacktrace", ent.Stack))
+	}
+	return c.handler.Handle(context.Background(), record)
+}
+
+func (c *slogCore) Sync() error {
+	return nil
+}
+
+// fieldsToAttrs encodes zap fields and converts the result to slog attributes.
+// Namespaces become nested groups.
+func fieldsToAttrs(fields []Field) []slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return mapToAttrs(enc.Fields)
+}
+
+func mapToAttrs(m map[string]interface{}) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(mapToAttrs(nested)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(k, v))
+	}
+	// Map iteration order is random; sort for stable output.
+	slices.SortFunc(attrs, func(a, b slog.Attr) int { return strings.Compare(a.Key, b.Key) })
+	return attrs
+}
@@ -0,0 +1,97 @@
+package zlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlogHandlerContextFieldsOutsideGroups(t *testing.T) {
+	var buf bytes.Buffer
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	h := NewSlogHandlerWithCore(zapcore.NewCore(enc, zapcore.AddSync(&buf), zapcore.DebugLevel))
+
+	ctx := context.WithValue(context.Background(), RequestIDKey, "req-1")
+	logger := slog.New(h).With("app", "shop").WithGroup("http").With("method", "GET").WithGroup("resp")
+	logger.InfoContext(ctx, "done", "status", 200)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	if got["request_id"] != "req-1" || got["app"] != "shop" {
+		t.Errorf("top-level fields missing: %s", buf.Bytes())
+	}
+	httpGroup, _ := got["http"].(map[string]interface{})
+	if httpGroup["method"] != "GET" {
+		t.Errorf("http.method missing: %s", buf.Bytes())
+	}
+	if _, ok := httpGroup["request_id"]; ok {
+		t.Errorf("request_id nested in group: %s", buf.Bytes())
+	}
+	resp, _ := httpGroup["resp"].(map[string]interface{})
+	if resp["status"] != float64(200) {
+		t.Errorf("http.resp.status missing: %s", buf.Bytes())
+	}
+}
+
+func TestSlogHandlerLoggerName(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l, err := NewWithCoreConfig(core, LoggerConfig{
+		Level:   InfoLevel,
+		Modules: map[string]Level{"payments": DebugLevel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slog.New(l.Named("payments").SlogHandler()).Debug("charge")
+	slog.New(l.Named("orders").SlogHandler()).Debug("create")
+	slog.New(l.Named("orders").SlogHandler()).Info("created")
+
+	entries := logs.TakeAll()
+	if len(entries) != 2 {
+		t.Fatalf("entries = %v, want the payments debug and orders info entries", entries)
+	}
+	if entries[0].Message != "charge" || entries[0].LoggerName != "payments" {
+		t.Errorf("entry = %q from %q, want charge from payments", entries[0].Message, entries[0].LoggerName)
+	}
+	if entries[1].LoggerName != "orders" {
+		t.Errorf("logger name = %q, want orders", entries[1].LoggerName)
+	}
+}
+
+func TestSetDefaultFollowsReplaceGlobals(t *testing.T) {
+	keepGlobals(t)
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	first, firstLogs := observer.New(zapcore.DebugLevel)
+	ReplaceGlobals(NewWithCore(first))
+	SetDefault()
+	logger := slog.Default().With("app", "shop").WithGroup("http")
+
+	second, secondLogs := observer.New(zapcore.DebugLevel)
+	ReplaceGlobals(NewWithCore(second).Named("api"))
+	logger.Info("request", "status", 200)
+
+	if n := firstLogs.Len(); n != 0 {
+		t.Errorf("replaced instance got %d entries", n)
+	}
+	entries := secondLogs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("current instance got %d entries, want 1", len(entries))
+	}
+	ctx := entries[0].ContextMap()
+	if ctx["app"] != "shop" || ctx["http"].(map[string]interface{})["status"] != int64(200) {
+		t.Errorf("fields = %v", ctx)
+	}
+	if entries[0].LoggerName != "api" {
+		t.Errorf("logger name = %q, want api", entries[0].LoggerName)
+	}
+}
@@ -0,0 +1,223 @@
+package zlog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Syslog message formats for OutputConfig.Protocol.
+const (
+	SyslogRFC3164 = "rfc3164" // BSD syslog, understood by every daemon
+	SyslogRFC5424 = "rfc5424"
+)
+
+// syslogFacilities maps facility names to their codes (RFC 5424 section 6.2.1).
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogFacilityUser is the "user-level messages" facility.
+const syslogFacilityUser = 1
+
+// SyslogSeverity maps a zlog Level to a syslog severity (RFC 5424 section 6.2.1).
+func (l Level) SyslogSeverity() int {
+	switch l {
+	case DebugLevel:
+		return 7 // debug
+	case InfoLevel:
+		return 6 // informational
+	case WarnLevel:
+		return 4 // warning
+	case ErrorLevel:
+		return 3 // error
+	case PanicLevel:
+		return 2 // critical
+	case FatalLevel:
+		return 1 // alert
+	default:
+		return 6
+	}
+}
+
+// parseSyslogFacility returns the code of a facility name; empty means "user".
+func parseSyslogFacility(name string) (int, error) {
+	if name == "" {
+		return syslogFacilityUser, nil
+	}
+	code, ok := syslogFacilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown syslog facility %q", name)
+	}
+	return code, nil
+}
+
+// parseSyslogAddress splits "udp://host:514", "tcp://host:601",
+// "unix:///run/syslog.sock" (stream) or "unixgram:///dev/log" into network
+// and address. A bare path means a unix datagram socket, as local syslog
+// daemons use, and a bare host:port means UDP. An empty address uses the
+// local /dev/log socket.
+func parseSyslogAddress(addr string) (network, address string) {
+	if addr == "" {
+		return OutputUnixgram, "/dev/log"
+	}
+	if i := strings.Index(addr, "://"); i >= 0 {
+		return addr[:i], addr[i+3:]
+	}
+	if strings.HasPrefix(addr, "/") {
+		return OutputUnixgram, addr
+	}
+	return OutputUDP, addr
+}
+
+// isDatagram reports whether network preserves message boundaries.
+func isDatagram(network string) bool {
+	return network == OutputUDP || network == OutputUnixgram
+}
+
+// newSyslogOutput builds a core that frames each entry as an RFC 3164 or
+// RFC 5424 message. Over stream connections messages are framed per RFC 6587:
+// octet counting for RFC 5424 and a trailing newline for RFC 3164.
+func newSyslogOutput(o OutputConfig, encCfg zapcore.EncoderConfig, enabler zapcore.LevelEnabler,
+	wrap func(zapcore.WriteSyncer) zapcore.WriteSyncer) (zapcore.Core, io.Closer, error) {
+
+	protocol := o.Protocol
+	if protocol == "" {
+		protocol = SyslogRFC3164
+	}
+	if protocol != SyslogRFC3164 && protocol != SyslogRFC5424 {
+		return nil, nil, fmt.Errorf("unknown syslog protocol %q", o.Protocol)
+	}
+	facility, err := parseSyslogFacility(o.Facility)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format := o.Format
+	if format == "" {
+		format = FormatJSON
+	}
+	// The syslog header already carries time and severity.
+	encCfg.TimeKey = zapcore.OmitKey
+	encCfg.LevelKey = zapcore.OmitKey
+	encCfg.LineEnding = "\n"
+	enc, err := newEncoder(format, encCfg, false, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	network, address := parseSyslogAddress(o.Address)
+	w := newNetWriter(network, address)
+	var out zapcore.WriteSyncer = w
+	if !isDatagram(network) {
+		// Buffering would merge datagrams, so only stream sockets go async.
+		out = wrap(w)
+	}
+
+	appName := o.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+	core := &syslogCore{
+		LevelEnabler: enabler,
+		enc:          enc,
+		out:          out,
+		protocol:     protocol,
+		stream:       !isDatagram(network),
+		facility:     facility,
+		appName:      appName,
+		hostname:     hostname,
+		pid:          os.Getpid(),
+	}
+	return core, w, nil
+}
+
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc      zapcore.Encoder
+	out      zapcore.WriteSyncer
+	protocol string
+	stream   bool
+	facility int
+	appName  string
+	hostname string
+	pid      int
+}
+
+func (c *syslogCore) With(fields []Field) zapcore.Core {
+	clone := *c
+	clone.enc = c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(clone.enc)
+	}
+	return &clone
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	body := strings.TrimRight(buf.String(), "\n")
+	pri := c.facility*8 + fromZapCoreLevel(ent.Level).SyslogSeverity()
+
+	var msg string
+	if c.protocol == SyslogRFC5424 {
+		// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+		msg = fmt.Sprintf("<%d>1 %s %s %s %d %s - %s", pri,
+			ent.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+			syslogHeaderField(c.hostname, 255), syslogHeaderField(c.appName, 48),
+			c.pid, syslogHeaderField(ent.LoggerName, 32), body)
+		if c.stream {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+	} else {
+		msg = fmt.Sprintf("<%d>%s %s %s[%d]: %s", pri, ent.Time.Format(time.Stamp),
+			c.hostname, c.appName, c.pid, body)
+		if c.stream {
+			msg += "\n"
+		}
+	}
+	_, err = c.out.Write([]byte(msg))
+	return err
+}
+
+func (c *syslogCore) Sync() error {
+	return c.out.Sync()
+}
+
+// syslogHeaderField returns s as an RFC 5424 header field: printable ASCII
+// without spaces, at most max characters, "-" when empty.
+func syslogHeaderField(s string, max int) string {
+	if s == "" {
+		return "-"
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > ' ' && s[i] < 0x7f {
+			b = append(b, s[i])
+		} else {
+			b = append(b, '_')
+		}
+	}
+	return string(b)
+}
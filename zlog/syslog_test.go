@@ -0,0 +1,213 @@
+//go:build unix
+
+package zlog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// syslogStreamListener accepts one stream connection on a unix socket and
+// returns the bytes it receives.
+func syslogStreamListener(t *testing.T) (string, *bufio.Reader) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	pr, pw := io.Pipe()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		defer conn.Close()
+		_, err = io.Copy(pw, conn)
+		pw.CloseWithError(err)
+	}()
+	t.Cleanup(func() { pr.Close() })
+	return path, bufio.NewReader(pr)
+}
+
+// readFrame reads an RFC 6587 octet-counted frame.
+func readFrame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	count, err := r.ReadString(' ')
+	if err != nil {
+		t.Fatalf("reading frame length: %v", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+	if err != nil {
+		t.Fatalf("bad frame length %q", count)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		t.Fatal(err)
+	}
+	return string(msg)
+}
+
+func newSyslogLogger(t *testing.T, o OutputConfig) *Logger {
+	t.Helper()
+	o.Type = OutputSyslog
+	l, err := New(LoggerConfig{Level: DebugLevel, Outputs: []OutputConfig{o}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestSyslogRFC5424OctetCounting(t *testing.T) {
+	path, r := syslogStreamListener(t)
+	l := newSyslogLogger(t, OutputConfig{Protocol: SyslogRFC5424, Address: "unix://" + path, Facility: "local0", AppName: "billing app"})
+
+	tests := []struct {
+		log  func(msg string, fields ...Field)
+		name string
+		pri  int
+	}{
+		{l.Debug, "", 16*8 + 7},
+		{l.Info, "", 16*8 + 6},
+		{l.Warn, "", 16*8 + 4},
+		{l.Named("payments").Error, "payments", 16*8 + 3},
+	}
+	host, _ := os.Hostname()
+	for i, tt := range tests {
+		// A newline in the message must not split the frame.
+		tt.log("line one\nline two", zap.Int("n", i))
+		frame := readFrame(t, r)
+
+		msgID := tt.name
+		if msgID == "" {
+			msgID = "-"
+		}
+		header := regexp.MustCompile(`^<(\d+)>1 (\S+) (\S+) (\S+) (\d+) (\S+) - `)
+		m := header.FindStringSubmatch(frame)
+		if m == nil {
+			t.Fatalf("frame %q has no RFC 5424 header", frame)
+		}
+		if m[1] != strconv.Itoa(tt.pri) {
+			t.Errorf("PRI = %s, want %d", m[1], tt.pri)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, m[2]); err != nil {
+			t.Errorf("timestamp %q: %v", m[2], err)
+		}
+		if m[3] != syslogHeaderField(host, 255) || m[4] != "billing_app" || m[5] != strconv.Itoa(os.Getpid()) || m[6] != msgID {
+			t.Errorf("header = %q", m[0])
+		}
+		if body := frame[len(m[0]):]; !strings.Contains(body, `"msg":"line one\nline two"`) || !strings.Contains(body, `"n":`+strconv.Itoa(i)) {
+			t.Errorf("body = %q", body)
+		}
+	}
+}
+
+func TestSyslogRFC3164Stream(t *testing.T) {
+	path, r := syslogStreamListener(t)
+	l := newSyslogLogger(t, OutputConfig{Address: "unix://" + path, Facility: "daemon", AppName: "shop", Format: FormatLogfmt})
+
+	l.Error("disk full", zap.String("mount", "/var"))
+	l.Info("recovered")
+	host, _ := os.Hostname()
+	for _, want := range []struct {
+		pri  int
+		body string
+	}{
+		{3*8 + 3, `msg="disk full" mount=/var`},
+		{3*8 + 6, `msg=recovered`},
+	} {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := "<" + strconv.Itoa(want.pri) + ">"
+		if !strings.HasPrefix(line, prefix) {
+			t.Fatalf("line %q does not start with %s", line, prefix)
+		}
+		stamp := line[len(prefix) : len(prefix)+len(time.Stamp)]
+		if _, err := time.Parse(time.Stamp, stamp); err != nil {
+			t.Errorf("timestamp %q: %v", stamp, err)
+		}
+		rest := line[len(prefix)+len(time.Stamp):]
+		if tag := " " + host + " shop[" + strconv.Itoa(os.Getpid()) + "]: "; !strings.HasPrefix(rest, tag) {
+			t.Errorf("line %q lacks %q", line, tag)
+		}
+		if !strings.Contains(rest, want.body) {
+			t.Errorf("line %q lacks %q", line, want.body)
+		}
+	}
+}
+
+func TestSyslogDatagram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A bare path is a datagram socket and messages carry no framing.
+	l := newSyslogLogger(t, OutputConfig{Protocol: SyslogRFC5424, Address: path})
+	l.Warn("hello")
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<12>1 ") || strings.HasSuffix(msg, "\n") {
+		t.Errorf("datagram = %q", msg)
+	}
+}
+
+func TestParseSyslogAddress(t *testing.T) {
+	tests := []struct {
+		addr, network, address string
+	}{
+		{"", OutputUnixgram, "/dev/log"},
+		{"/dev/log", OutputUnixgram, "/dev/log"},
+		{"unix:///run/syslog.sock", OutputUnix, "/run/syslog.sock"},
+		{"unixgram:///dev/log", OutputUnixgram, "/dev/log"},
+		{"tcp://rsyslog:601", OutputTCP, "rsyslog:601"},
+		{"udp://rsyslog:514", OutputUDP, "rsyslog:514"},
+		{"rsyslog:514", OutputUDP, "rsyslog:514"},
+	}
+	for _, tt := range tests {
+		network, address := parseSyslogAddress(tt.addr)
+		if network != tt.network || address != tt.address {
+			t.Errorf("parseSyslogAddress(%q) = %s, %s; want %s, %s", tt.addr, network, address, tt.network, tt.address)
+		}
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	want := map[Level]int{DebugLevel: 7, InfoLevel: 6, WarnLevel: 4, ErrorLevel: 3, PanicLevel: 2, FatalLevel: 1}
+	for lvl, sev := range want {
+		if got := lvl.SyslogSeverity(); got != sev {
+			t.Errorf("%v.SyslogSeverity() = %d, want %d", lvl, got, sev)
+		}
+	}
+	for name, code := range map[string]int{"": 1, "user": 1, "LOCAL7": 23, "authpriv": 10} {
+		if got, err := parseSyslogFacility(name); err != nil || got != code {
+			t.Errorf("parseSyslogFacility(%q) = %d, %v", name, got, err)
+		}
+	}
+	if _, err := parseSyslogFacility("local8"); err == nil {
+		t.Error("unknown facility accepted")
+	}
+}
@@ -0,0 +1,148 @@
+package zlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// traceContext parses and injects W3C traceparent/tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// EnableSpanEvents turns recording of *Ctx log entries as span events on or off
+// for the default instance. New sets it from LoggerConfig.SpanEvents.
+func EnableSpanEvents(enabled bool) {
+	L().EnableSpanEvents(enabled)
+}
+
+// ContextWithTraceparent returns a copy of ctx carrying the remote span context
+// described by the W3C traceparent (and optional tracestate) header values.
+// ctx is returned unchanged if traceparent is malformed.
+func ContextWithTraceparent(ctx context.Context, traceparent, tracestate string) context.Context {
+	carrier := propagation.MapCarrier{"traceparent": traceparent}
+	if tracestate != "" {
+		carrier["tracestate"] = tracestate
+	}
+	return traceContext.Extract(ctx, carrier)
+}
+
+// TraceContextHandler is a net/http middleware that extracts the W3C traceparent
+// header into the request context so *Ctx calls log trace_id and span_id.
+func TraceContextHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(extractTraceparent(r)))
+	})
+}
+
+// GinTraceContext is the Gin equivalent of TraceContextHandler.
+func GinTraceContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(extractTraceparent(c.Request))
+		c.Next()
+	}
+}
+
+// extractTraceparent keeps a span already started by instrumentation and
+// otherwise falls back to the incoming traceparent header.
+func extractTraceparent(r *http.Request) context.Context {
+	ctx := r.Context()
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	return traceContext.Extract(ctx, propagation.HeaderCarrier(r.Header))
+}
+
+// spanEventsEnabled reports whether an entry at level is recorded on the span
+// in ctx: span events are on, the span is recording and l writes the level.
+func (l *Logger) spanEventsEnabled(ctx context.Context, level Level) bool {
+	return l.spanEvents.Load() && trace.SpanFromContext(ctx).IsRecording() && l.enabled(level)
+}
+
+func (l *Logger) addSpanEvent(ctx context.Context, level Level, msg string, fields []Field) {
+	if !l.spanEventsEnabled(ctx, level) {
+		return
+	}
+	// Span events leave the process through the trace exporter, so they are
+	// masked like the outputs.
+	if l.parts.redactor != nil {
+		msg = l.parts.redactor.RedactString(msg)
+		fields = l.parts.redactor.RedactFields(fields)
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	attrs := make([]attribute.KeyValue, 0, len(enc.Fields)+1)
+	attrs = append(attrs, attribute.String("log.severity", level.String()))
+	for k, v := range enc.Fields {
+		attrs = append(attrs, toAttribute(k, v))
+	}
+	trace.SpanFromContext(ctx).AddEvent(msg, trace.WithAttributes(attrs...))
+}
+
+func (l *Logger) addSpanEventf(ctx context.Context, level Level, format string, args []interface{}) {
+	if !l.spanEventsEnabled(ctx, level) {
+		return
+	}
+	l.addSpanEvent(ctx, level, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *Logger) addSpanEventw(ctx context.Context, level Level, msg string, keysAndValues []interface{}) {
+	if !l.spanEventsEnabled(ctx, level) {
+		return
+	}
+	l.addSpanEvent(ctx, level, msg, sweetenFields(keysAndValues))
+}
+
+// sweetenFields converts loosely-typed key-value pairs into the fields a
+// SugaredLogger would write: inline Fields are kept as they are, pairs with
+// a non-string key and a trailing key without a value are dropped.
+func sweetenFields(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); {
+		if f, ok := keysAndValues[i].(Field); ok {
+			fields = append(fields, f)
+			i++
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			break
+		}
+		if key, ok := keysAndValues[i].(string); ok {
+			fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+		}
+		i += 2
+	}
+	return fields
+}
+
+func toAttribute(key string, v interface{}) attribute.KeyValue {
+	switch val := v.(type) {
+	case string:
+		return attribute.String(key, val)
+	case bool:
+		return attribute.Bool(key, val)
+	case int:
+		return attribute.Int(key, val)
+	case int64:
+		return attribute.Int64(key, val)
+	case float64:
+		return attribute.Float64(key, val)
+	case time.Duration:
+		return attribute.String(key, val.String())
+	case time.Time:
+		return attribute.String(key, val.Format(time.RFC3339Nano))
+	case error:
+		return attribute.String(key, val.Error())
+	default:
+		return attribute.String(key, fmt.Sprint(val))
+	}
+}
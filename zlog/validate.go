@@ -0,0 +1,384 @@
+package zlog
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FieldError is one problem found in a LoggerConfig.
+type FieldError struct {
+	Field   string // yaml path such as "outputs[1].format"
+	Problem string
+
+	// normalizable problems are replaced by defaults unless Strict is set.
+	normalizable bool
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// ValidationError lists every problem found in a LoggerConfig.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid logger config: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual problems for errors.As and errors.Is.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, fe := range e.Errors {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// validator collects problems while walking a config.
+type validator struct {
+	errs []*FieldError
+}
+
+// fail records a problem that prevents building the logger.
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Problem: fmt.Sprintf(format, args...)})
+}
+
+// warn records a problem that New replaces with a default unless Strict is set.
+func (v *validator) warn(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, &FieldError{Field: field, Problem: fmt.Sprintf(format, args...), normalizable: true})
+}
+
+// err returns the problems as a *ValidationError, or nil. Normalizable
+// problems are included only when strict is set.
+func (v *validator) err(strict bool) error {
+	var errs []*FieldError
+	for _, fe := range v.errs {
+		if strict || !fe.normalizable {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// Validate reports every problem in c at once as a *ValidationError without
+// modifying c: unknown levels, outputs and formats, missing paths and
+// addresses, negative rotation values, invalid sampling, async, redaction and
+// rotation settings, and log directories that cannot be written. Zero values
+// are not problems; they select the documented defaults.
+func (c LoggerConfig) Validate() error {
+	return c.validate().err(true)
+}
+
+func (c LoggerConfig) validate() *validator {
+	v := &validator{}
+	if c.Level != "" && !c.Level.Valid() {
+		v.warn("level", "unknown level %q", c.Level)
+	}
+	switch c.Output {
+	case "", "console", "file", "both":
+	default:
+		v.warn("output", "unknown output %q, want console, file or both", c.Output)
+	}
+	switch c.Format {
+	case "", FormatJSON, FormatConsole, FormatLogfmt:
+	default:
+		v.warn("format", "unknown format %q, want json, console or logfmt", c.Format)
+	}
+	checkNonNegative(v, "", map[string]int{"max_size": c.MaxSize, "max_backups": c.MaxBackups, "max_age": c.MaxAge})
+
+	if len(c.Outputs) == 0 && (c.Output == "file" || c.Output == "both") {
+		if c.FilePath == "" {
+			v.fail("file_path", "required when output is %q", c.Output)
+		} else {
+			checkWritable(v, "file_path", c.FilePath)
+		}
+	}
+	for i, o := range c.Outputs {
+		validateOutput(v, fmt.Sprintf("outputs[%d]", i), o)
+	}
+	if c.Rotation != nil {
+		validateRotation(v, "rotation", *c.Rotation)
+	}
+	if c.Encoding != nil {
+		validateEncoding(v, "encoding", *c.Encoding)
+	}
+	for _, name := range sortedKeys(c.Modules) {
+		if name == "" {
+			v.warn("modules", "empty module name")
+		}
+		if lvl := c.Modules[name]; !lvl.Valid() {
+			v.warn("modules."+name, "unknown level %q", lvl)
+		}
+	}
+	if c.Recent != nil {
+		checkNonNegative(v, "recent.", map[string]int{"size": c.Recent.Size})
+		if lvl := c.Recent.Level; lvl != "" && !lvl.Valid() {
+			v.warn("recent.level", "unknown level %q", lvl)
+		}
+	}
+	if c.Sampler != nil {
+		validateSampling(v, "sampler", *c.Sampler)
+	}
+	if c.Async != nil {
+		validateAsync(v, "async", *c.Async)
+	}
+	if c.Redact != nil {
+		if _, err := NewRedactor(*c.Redact); err != nil {
+			v.fail("redact", "%v", err)
+		}
+	}
+	return v
+}
+
+// normalized returns c with the problems reported as normalizable replaced
+// by defaults.
+func (c LoggerConfig) normalized() LoggerConfig {
+	if !c.Level.Valid() {
+		c.Level = InfoLevel
+	}
+	switch c.Output {
+	case "console", "file", "both":
+	default:
+		c.Output = "console"
+	}
+	switch c.Format {
+	case FormatJSON, FormatConsole, FormatLogfmt:
+	default:
+		c.Format = FormatConsole
+	}
+	if c.MaxSize <= 0 {
+		c.MaxSize = 100 // MB
+	}
+	if c.MaxBackups < 0 {
+		c.MaxBackups = 10
+	}
+	if c.MaxAge < 0 {
+		c.MaxAge = 30 // days
+	}
+	return c
+}
+
+func validateOutput(v *validator, field string, o OutputConfig) {
+	if o.Level != "" && !o.Level.Valid() {
+		v.warn(field+".level", "unknown level %q", o.Level)
+	}
+	if o.MaxLevel != "" && !o.MaxLevel.Valid() {
+		v.warn(field+".max_level", "unknown level %q", o.MaxLevel)
+	}
+	if o.Level.Valid() && o.MaxLevel.Valid() && o.MaxLevel.Severity() < o.Level.Severity() {
+		v.warn(field+".max_level", "%q is below level %q, the output writes nothing", o.MaxLevel, o.Level)
+	}
+	switch o.Format {
+	case "", FormatJSON, FormatConsole, FormatLogfmt:
+	default:
+		v.fail(field+".format", "unknown format %q, want json, console or logfmt", o.Format)
+	}
+	checkNonNegative(v, field+".", map[string]int{"max_size": o.MaxSize, "max_backups": o.MaxBackups, "max_age": o.MaxAge})
+	if o.Rotation != nil {
+		validateRotation(v, field+".rotation", *o.Rotation)
+	}
+	if o.Encoding != nil {
+		validateEncoding(v, field+".encoding", *o.Encoding)
+	}
+
+	switch o.Type {
+	case OutputStdout, OutputStderr, OutputJournald:
+	case OutputFile:
+		if o.Path == "" {
+			v.fail(field+".path", "required for file output")
+		} else {
+			checkWritable(v, field+".path", o.Path)
+		}
+	case OutputTCP, OutputUDP, OutputUnix, OutputUnixgram:
+		if o.Address == "" {
+			v.fail(field+".address", "required for %s output", o.Type)
+		}
+	case OutputSyslog:
+		switch o.Protocol {
+		case "", SyslogRFC3164, SyslogRFC5424:
+		default:
+			v.fail(field+".protocol", "unknown syslog protocol %q", o.Protocol)
+		}
+	case OutputLoki, OutputOTLP:
+		if o.URL == "" {
+			v.fail(field+".url", "required for %s output", o.Type)
+		} else if u, err := url.Parse(o.URL); err != nil || u.Host == "" {
+			v.fail(field+".url", "invalid url %q", o.URL)
+		}
+		if s := o.Ship; s != nil {
+			checkNonNegative(v, field+".ship.", map[string]int{
+				"batch_size": s.BatchSize, "queue_size": s.QueueSize, "spool_max_size": s.SpoolMaxSize,
+			})
+			checkNonNegative(v, field+".ship.", map[string]time.Duration{
+				"batch_wait": s.BatchWait, "timeout": s.Timeout, "min_backoff": s.MinBackoff, "max_backoff": s.MaxBackoff,
+			})
+		}
+	case "":
+		v.fail(field+".type", "required")
+	default:
+		v.fail(field+".type", "unknown output type %q", o.Type)
+	}
+	if o.Type == OutputSyslog || o.Type == OutputJournald {
+		if _, err := parseSyslogFacility(o.Facility); err != nil {
+			v.fail(field+".facility", "unknown syslog facility %q", o.Facility)
+		}
+	}
+}
+
+func validateRotation(v *validator, field string, r RotationConfig) {
+	switch r.Interval {
+	case "", RotateDaily, RotateHourly, RotateNever:
+	default:
+		v.fail(field+".interval", "unknown rotation interval %q, want daily, hourly or never", r.Interval)
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			v.fail(field+".timezone", "unknown timezone %q", r.Timezone)
+		}
+	}
+	switch r.Compression {
+	case "", CompressGzip, CompressZstd:
+	default:
+		v.fail(field+".compression", "unknown compression %q, want gzip or zstd", r.Compression)
+	}
+	checkNonNegative(v, field+".", map[string]int{
+		"max_size": r.MaxSize, "max_backups": r.MaxBackups, "max_age": r.MaxAge, "max_total_size": r.MaxTotalSize,
+	})
+}
+
+func validateEncoding(v *validator, field string, e EncodingConfig) {
+	if e.Timezone != "" {
+		if _, err := time.LoadLocation(e.Timezone); err != nil {
+			v.fail(field+".timezone", "unknown timezone %q", e.Timezone)
+		}
+	}
+	if e.Layout != "" {
+		if _, err := parseLayout(e.Layout); err != nil {
+			v.fail(field+".layout", "%v", err)
+		}
+	}
+	switch e.Color {
+	case "", ColorAlways, ColorAuto, ColorNever:
+	default:
+		v.warn(field+".color", "unknown color mode %q, want always, auto or never", e.Color)
+	}
+}
+
+func validateSampling(v *validator, field string, s SamplingConfig) {
+	checkNonNegative(v, field+".", map[string]time.Duration{"tick": s.Tick, "summary_interval": s.SummaryInterval})
+	if s.First < 0 {
+		v.warn(field+".first", "must not be negative, got %d", s.First)
+	}
+	for lvl := range s.Levels {
+		if !lvl.Valid() {
+			v.warn(field+".levels", "unknown level %q", lvl)
+		}
+	}
+	for msg, d := range s.RateLimits {
+		if d <= 0 {
+			v.warn(field+".rate_limits", "interval for %q must be positive, got %s", msg, d)
+		}
+	}
+}
+
+func validateAsync(v *validator, field string, a AsyncConfig) {
+	switch a.Policy {
+	case "", AsyncBlock, AsyncDropNewest, AsyncDropOldest:
+	default:
+		v.warn(field+".policy", "unknown policy %q, want block, drop_newest or drop_oldest", a.Policy)
+	}
+	checkNonNegative(v, field+".", map[string]int{"buffer_size": a.BufferSize})
+	checkNonNegative(v, field+".", map[string]time.Duration{"flush_interval": a.FlushInterval})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkNonNegative reports negative values in key order.
+func checkNonNegative[T int | time.Duration](v *validator, prefix string, values map[string]T) {
+	for _, k := range sortedKeys(values) {
+		if n := values[k]; n < 0 {
+			v.warn(prefix+k, "must not be negative, got %v", n)
+		}
+	}
+}
+
+// checkWritable reports a log file that cannot be appended to, or a
+// directory in which it cannot be created. Missing directories are fine as
+// long as the nearest existing ancestor is writable, since they are created.
+func checkWritable(v *validator, field, path string) {
+	if !filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			path = filepath.Join(wd, path)
+		}
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			v.fail(field, "%q is a directory", path)
+			return
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			v.fail(field, "log file is not writable: %v", unwrapPathError(err))
+			return
+		}
+		f.Close()
+		return
+	}
+
+	dir := filepath.Dir(path)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				v.fail(field, "%q is not a directory", dir)
+				return
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+	}
+	// Probe with a temporary file rather than permission bits, which miss
+	// read-only mounts and ACLs.
+	f, err := os.CreateTemp(dir, ".zlog-probe-*")
+	if err != nil {
+		v.fail(field, "log directory %q is not writable: %v", dir, unwrapPathError(err))
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+func unwrapPathError(err error) error {
+	var pe *os.PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+	return err
+}
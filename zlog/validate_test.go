@@ -0,0 +1,177 @@
+package zlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// fileConfig returns a config writing JSON to a file in a temporary directory.
+func fileConfig(t *testing.T, level Level) LoggerConfig {
+	t.Helper()
+	return LoggerConfig{
+		Level:   level,
+		Outputs: []OutputConfig{{Type: OutputFile, Path: filepath.Join(t.TempDir(), "app.log"), Format: FormatJSON}},
+	}
+}
+
+func TestNewKeepsLevel(t *testing.T) {
+	tests := []struct {
+		level    Level
+		disabled zapcore.Level
+		enabled  zapcore.Level
+	}{
+		{DebugLevel, zapcore.DebugLevel - 1, zapcore.DebugLevel},
+		{InfoLevel, zapcore.DebugLevel, zapcore.InfoLevel},
+		{WarnLevel, zapcore.InfoLevel, zapcore.WarnLevel},
+		{ErrorLevel, zapcore.WarnLevel, zapcore.ErrorLevel},
+		{PanicLevel, zapcore.ErrorLevel, zapcore.PanicLevel},
+		{FatalLevel, zapcore.PanicLevel, zapcore.FatalLevel},
+	}
+	for _, tt := range tests {
+		l, err := New(fileConfig(t, tt.level))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.level, err)
+		}
+		core := l.Zap().Core()
+		if core.Enabled(tt.disabled) || !core.Enabled(tt.enabled) {
+			t.Errorf("Level %q: enabled from %v on, want %v", tt.level, !core.Enabled(tt.disabled), tt.enabled)
+		}
+		l.Close()
+	}
+}
+
+func TestLevelUnmarshalText(t *testing.T) {
+	for text, want := range map[string]Level{
+		"debug": DebugLevel, "I": InfoLevel, "warning": WarnLevel, "WARN": WarnLevel,
+		"err": ErrorLevel, "p": PanicLevel, "Fatal": FatalLevel,
+	} {
+		var l Level
+		if err := l.UnmarshalText([]byte(text)); err != nil || l != want {
+			t.Errorf("UnmarshalText(%q) = %q, %v; want %q", text, l, err, want)
+		}
+	}
+	var l Level
+	if err := l.UnmarshalText([]byte("verbose")); err == nil {
+		t.Error("unknown level accepted")
+	}
+	if b, _ := Level("verbose").MarshalText(); string(b) != "info" {
+		t.Errorf("MarshalText of an unknown level = %q", b)
+	}
+}
+
+// fieldErrors returns the fields named by a *ValidationError.
+func fieldErrors(t *testing.T, err error) []string {
+	t.Helper()
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("err = %v, want *ValidationError", err)
+	}
+	fields := make([]string, len(ve.Errors))
+	for i, fe := range ve.Errors {
+		fields[i] = fe.Field
+	}
+	return fields
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := LoggerConfig{
+		Level:   "verbose",
+		Format:  "xml",
+		MaxSize: -1,
+		Outputs: []OutputConfig{
+			{Type: OutputFile},
+			{Type: "kafka"},
+			{Type: OutputStdout, Format: "yaml", Level: WarnLevel, MaxLevel: InfoLevel},
+			{Type: OutputLoki, URL: "not a url"},
+		},
+		Rotation: &RotationConfig{Interval: "weekly", Compression: "lz4", MaxBackups: -2},
+		Modules:  map[string]Level{"db": "loud"},
+		Redact:   &RedactConfig{Strategy: "blur"},
+	}
+	got := strings.Join(fieldErrors(t, c.Validate()), ",")
+	want := strings.Join([]string{
+		"level", "format", "max_size",
+		"outputs[0].path", "outputs[1].type",
+		"outputs[2].max_level", "outputs[2].format",
+		"outputs[3].url",
+		"rotation.interval", "rotation.compression", "rotation.max_backups",
+		"modules.db", "redact",
+	}, ",")
+	if got != want {
+		t.Errorf("Validate fields:\n got %s\nwant %s", got, want)
+	}
+
+	var fe *FieldError
+	if !errors.As(c.Validate(), &fe) || fe.Field != "level" {
+		t.Errorf("errors.As(*FieldError) = %v", fe)
+	}
+	if err := fileConfig(t, InfoLevel).Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	if err := (LoggerConfig{}).Validate(); err != nil {
+		t.Errorf("zero config: %v", err)
+	}
+}
+
+func TestStrictRejectsNormalizedValues(t *testing.T) {
+	loose := fileConfig(t, "verbose")
+	loose.Outputs[0].Level = "chatty"
+	loose.Async = &AsyncConfig{Policy: "drop_all"}
+
+	l, err := New(loose)
+	if err != nil {
+		t.Fatalf("non-strict New: %v", err)
+	}
+	if core := l.Zap().Core(); core.Enabled(zapcore.DebugLevel) || !core.Enabled(zapcore.InfoLevel) {
+		t.Error("unknown level not normalized to info")
+	}
+	l.Close()
+
+	strict := loose
+	strict.Strict = true
+	got := strings.Join(fieldErrors(t, func() error { _, err := New(strict); return err }()), ",")
+	if got != "level,outputs[0].level,async.policy" {
+		t.Errorf("strict New fields = %s", got)
+	}
+
+	// Problems without a default fail either way.
+	broken := fileConfig(t, InfoLevel)
+	broken.Outputs[0].Path = ""
+	if _, err := New(broken); err == nil {
+		t.Error("non-strict New accepted a file output without a path")
+	}
+}
+
+func TestValidateUnwritableDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "plain")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := LoggerConfig{Outputs: []OutputConfig{
+		{Type: OutputFile, Path: filepath.Join(file, "logs", "app.log")},
+		{Type: OutputFile, Path: dir},
+		{Type: OutputFile, Path: filepath.Join(dir, "new", "nested", "app.log")},
+	}}
+	if got := strings.Join(fieldErrors(t, c.Validate()), ","); got != "outputs[0].path,outputs[1].path" {
+		t.Errorf("Validate fields = %s", got)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits do not restrict root")
+	}
+	readOnly := filepath.Join(dir, "ro")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+	c = LoggerConfig{Output: "file", FilePath: filepath.Join(readOnly, "app.log")}
+	err := c.Validate()
+	if got := strings.Join(fieldErrors(t, err), ","); got != "file_path" || !strings.Contains(err.Error(), "not writable") {
+		t.Errorf("read-only directory: %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Package zlogq reads, filters and pretty-prints JSON log files written by
+// zlog, including rotated and compressed backups. The zlogq command in
+// cmd/zlogq is a thin wrapper around it.
+package zlogq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzanhong/goutil/zlog"
+)
+
+// Keys of the entry metadata written by zlog's JSON encoder.
+const (
+	TimeKey       = "ts"
+	LevelKey      = "level"
+	LoggerKey     = "logger"
+	CallerKey     = "caller"
+	MessageKey    = "msg"
+	StacktraceKey = "stacktrace"
+)
+
+// Keys names the entry metadata in a log line. An empty key means the entry
+// has no such metadata.
+type Keys struct {
+	Time       string
+	Level      string
+	Logger     string
+	Caller     string
+	Message    string
+	Stacktrace string
+}
+
+// DefaultKeys are the keys zlog writes unless LoggerConfig.Encoding renames them.
+var DefaultKeys = Keys{
+	Time:       TimeKey,
+	Level:      LevelKey,
+	Logger:     LoggerKey,
+	Caller:     CallerKey,
+	Message:    MessageKey,
+	Stacktrace: StacktraceKey,
+}
+
+// KeysFor returns the keys of entries written with enc; nil yields DefaultKeys.
+func KeysFor(enc *zlog.EncodingConfig) Keys {
+	if enc == nil {
+		return DefaultKeys
+	}
+	key := func(k, def string) string {
+		switch k {
+		case "":
+			return def
+		case zlog.OmitKey:
+			return ""
+		}
+		return k
+	}
+	return Keys{
+		Time:       key(enc.TimeKey, TimeKey),
+		Level:      key(enc.LevelKey, LevelKey),
+		Logger:     key(enc.NameKey, LoggerKey),
+		Caller:     key(enc.CallerKey, CallerKey),
+		Message:    key(enc.MessageKey, MessageKey),
+		Stacktrace: key(enc.StacktraceKey, StacktraceKey),
+	}
+}
+
+// Entry is one parsed log line.
+type Entry struct {
+	Time    time.Time
+	Level   zlog.Level
+	Logger  string
+	Caller  string
+	Message string
+	Stack   string
+	Fields  map[string]interface{} // remaining keys, nested objects as maps
+	Raw     []byte                 // the original line without line ending
+
+	keys Keys
+}
+
+// ParseEntry parses one JSON log line written with DefaultKeys.
+func ParseEntry(line []byte) (*Entry, error) {
+	return ParseEntryKeys(line, DefaultKeys)
+}
+
+// ParseEntryKeys parses one JSON log line whose metadata uses keys.
+func ParseEntryKeys(line []byte, keys Keys) (*Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("not a JSON log entry: %w", err)
+	}
+
+	e := &Entry{Raw: append([]byte(nil), line...), keys: keys}
+	e.Time = parseTime(metadata(m, keys.Time))
+	if lvl, ok := metadata(m, keys.Level).(string); ok {
+		_ = e.Level.UnmarshalText([]byte(lvl))
+		if e.Level == "" {
+			e.Level = zlog.Level(lvl)
+		}
+	}
+	e.Logger, _ = metadata(m, keys.Logger).(string)
+	e.Caller, _ = metadata(m, keys.Caller).(string)
+	e.Message, _ = metadata(m, keys.Message).(string)
+	e.Stack, _ = metadata(m, keys.Stacktrace).(string)
+	e.Fields = m
+	return e, nil
+}
+
+// metadata removes key from m and returns its value.
+func metadata(m map[string]interface{}, key string) interface{} {
+	if key == "" {
+		return nil
+	}
+	v := m[key]
+	delete(m, key)
+	return v
+}
+
+// parseTime accepts the ISO8601/RFC3339 strings and epoch numbers zlog can write.
+func parseTime(v interface{}) time.Time {
+	switch v := v.(type) {
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700", "2006-01-02 15:04:05.000"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}
+		}
+		// Heuristic: milliseconds and nanoseconds are far beyond second epochs.
+		switch {
+		case f > 1e17:
+			return time.Unix(0, int64(f))
+		case f > 1e11:
+			return time.UnixMilli(int64(f))
+		default:
+			sec, frac := math.Modf(f)
+			return time.Unix(int64(sec), int64(frac*1e9))
+		}
+	}
+	return time.Time{}
+}
+
+// Field returns the value at a dotted path such as "user.id". The message,
+// level, logger and caller are found under the keys the entry was parsed with.
+func (e *Entry) Field(path string) (interface{}, bool) {
+	keys := e.keys
+	if keys == (Keys{}) {
+		keys = DefaultKeys
+	}
+	if path != "" {
+		switch path {
+		case keys.Message:
+			return e.Message, true
+		case keys.Level:
+			return string(e.Level), true
+		case keys.Logger:
+			return e.Logger, e.Logger != ""
+		case keys.Caller:
+			return e.Caller, e.Caller != ""
+		}
+	}
+	var cur interface{} = e.Fields
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// levelRank orders levels by severity; unknown levels rank as info.
+func levelRank(l zlog.Level) int {
+	if !l.Valid() {
+		return zlog.InfoLevel.Severity()
+	}
+	return l.Severity()
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valueString renders a field value for comparisons.
+func valueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,72 @@
+package zlogq
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chenzanhong/goutil/zlog"
+)
+
+func TestScanRenamedKeys(t *testing.T) {
+	enc := &zlog.EncodingConfig{TimeKey: "time", LevelKey: "severity", MessageKey: "message", CallerKey: zlog.OmitKey}
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, err := zlog.New(zlog.LoggerConfig{
+		Level:    zlog.InfoLevel,
+		Encoding: enc,
+		Outputs:  []zlog.OutputConfig{{Type: zlog.OutputFile, Path: path, Format: zlog.FormatJSON}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("started", zlog.String("msg", "a field"))
+	l.Named("db").Error("query failed", zlog.Int("status", 500))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	keys := KeysFor(enc)
+	expr, _ := ParseFieldExpr("message~failed")
+	var got []*Entry
+	err = Scan(f, &Filter{MinLevel: zlog.WarnLevel, Keys: &keys, Where: []FieldExpr{expr}}, func(e *Entry) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("matched %d entries, want 1", len(got))
+	}
+	e := got[0]
+	if e.Message != "query failed" || e.Level != zlog.ErrorLevel || e.Logger != "db" || e.Time.IsZero() {
+		t.Errorf("metadata not parsed: %+v", e)
+	}
+	if _, ok := e.Fields["time"]; ok || len(e.Fields) != 1 {
+		t.Errorf("fields = %v, want only status", e.Fields)
+	}
+
+	// The first entry keeps its own "msg" field when parsed with the right keys.
+	first, err := ParseEntryKeys([]byte(strings.SplitN(string(readFile(t, path)), "\n", 2)[0]), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Message != "started" || first.Fields["msg"] != "a field" {
+		t.Errorf("first entry = %q, fields %v", first.Message, first.Fields)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,174 @@
+package zlogq
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chenzanhong/goutil/zlog"
+)
+
+// Filter selects entries. Zero-valued criteria match everything.
+type Filter struct {
+	MinLevel  zlog.Level // lowest level shown
+	Since     time.Time
+	Until     time.Time
+	RequestID string
+	TraceID   string
+	Where     []FieldExpr // all must match
+
+	// Keys names the metadata of the entries being read, for logs written
+	// with renamed keys (see KeysFor); nil means DefaultKeys.
+	Keys *Keys
+}
+
+// parse parses line with the keys of f.
+func (f *Filter) parse(line []byte) (*Entry, error) {
+	if f == nil || f.Keys == nil {
+		return ParseEntry(line)
+	}
+	return ParseEntryKeys(line, *f.Keys)
+}
+
+// Match reports whether e satisfies every criterion of f.
+func (f *Filter) Match(e *Entry) bool {
+	if f == nil {
+		return true
+	}
+	if f.MinLevel != "" && levelRank(e.Level) < levelRank(f.MinLevel) {
+		return false
+	}
+	if !f.Since.IsZero() && (e.Time.IsZero() || e.Time.Before(f.Since)) {
+		return false
+	}
+	if !f.Until.IsZero() && (e.Time.IsZero() || !e.Time.Before(f.Until)) {
+		return false
+	}
+	if f.RequestID != "" && !fieldEquals(e, "request_id", f.RequestID) {
+		return false
+	}
+	if f.TraceID != "" && !fieldEquals(e, "trace_id", f.TraceID) {
+		return false
+	}
+	for _, w := range f.Where {
+		if !w.Match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldEquals(e *Entry, key, want string) bool {
+	v, ok := e.Field(key)
+	return ok && valueString(v) == want
+}
+
+// FieldExpr compares a field with a value, see ParseFieldExpr.
+type FieldExpr struct {
+	Path  string
+	Op    string // "", "=", "!=", ">", ">=", "<", "<=", "~"
+	Value string
+	re    *regexp.Regexp
+}
+
+// exprOps lists operators, longer ones first so ">=" wins over ">".
+var exprOps = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// ParseFieldExpr parses expressions such as "status>=500", "user.id=42",
+// "path~^/api/" (regular expression), "error" (field present) or "!error"
+// (field absent). Comparisons are numeric when both sides are numbers.
+func ParseFieldExpr(s string) (FieldExpr, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return FieldExpr{}, fmt.Errorf("empty field expression")
+	}
+	// The first operator in s wins; at equal positions the longer one.
+	pos, op := -1, ""
+	for _, o := range exprOps {
+		if i := strings.Index(s, o); i > 0 && (pos < 0 || i < pos) {
+			pos, op = i, o
+		}
+	}
+	if pos > 0 {
+		expr := FieldExpr{Path: strings.TrimSpace(s[:pos]), Op: op, Value: strings.TrimSpace(s[pos+len(op):])}
+		if op == "~" {
+			re, err := regexp.Compile(expr.Value)
+			if err != nil {
+				return FieldExpr{}, fmt.Errorf("invalid pattern in %q: %w", s, err)
+			}
+			expr.re = re
+		}
+		return expr, nil
+	}
+	if strings.HasPrefix(s, "!") {
+		return FieldExpr{Path: s[1:], Op: "!"}, nil
+	}
+	return FieldExpr{Path: s}, nil
+}
+
+// Match reports whether e satisfies the expression.
+func (x FieldExpr) Match(e *Entry) bool {
+	v, ok := e.Field(x.Path)
+	switch x.Op {
+	case "":
+		return ok
+	case "!":
+		return !ok
+	}
+	if !ok {
+		return x.Op == "!="
+	}
+	got := valueString(v)
+	switch x.Op {
+	case "=":
+		return got == x.Value
+	case "!=":
+		return got != x.Value
+	case "~":
+		return x.re.MatchString(got)
+	}
+
+	a, errA := strconv.ParseFloat(got, 64)
+	b, errB := strconv.ParseFloat(x.Value, 64)
+	var cmp int
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(got, x.Value)
+	}
+	switch x.Op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// ParseTime parses an absolute time (RFC 3339, "2006-01-02 15:04:05" or a
+// date in local time) or a duration relative to now, such as "15m" or "2h".
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
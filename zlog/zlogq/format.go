@@ -0,0 +1,93 @@
+package zlogq
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/chenzanhong/goutil/zlog"
+)
+
+// ANSI colors used by zap's CapitalColorLevelEncoder.
+var levelColors = map[zlog.Level]string{
+	zlog.DebugLevel: "\x1b[35m",
+	zlog.InfoLevel:  "\x1b[34m",
+	zlog.WarnLevel:  "\x1b[33m",
+	zlog.ErrorLevel: "\x1b[31m",
+	zlog.PanicLevel: "\x1b[31m",
+	zlog.FatalLevel: "\x1b[31m",
+}
+
+const colorReset = "\x1b[0m"
+
+// Printer writes entries in the layout of zlog's console encoder:
+// time, level, logger, caller and message separated by tabs, followed by the
+// remaining fields as JSON and the stack trace on the next lines.
+type Printer struct {
+	W     io.Writer
+	Color bool // colorize levels like the console encoder
+}
+
+// Print writes one entry.
+func (p *Printer) Print(e *Entry) error {
+	_, err := io.WriteString(p.W, Format(e, p.Color))
+	return err
+}
+
+// Format renders e in the console layout, ending with a newline.
+func Format(e *Entry, color bool) string {
+	var b strings.Builder
+	if !e.Time.IsZero() {
+		b.WriteString(e.Time.Format("2006-01-02T15:04:05.000Z0700"))
+		b.WriteByte('\t')
+	}
+	level := strings.ToUpper(string(e.Level))
+	if c, ok := levelColors[e.Level]; ok && color {
+		level = c + level + colorReset
+	}
+	b.WriteString(level)
+	if e.Logger != "" {
+		b.WriteByte('\t')
+		b.WriteString(e.Logger)
+	}
+	if e.Caller != "" {
+		b.WriteByte('\t')
+		b.WriteString(e.Caller)
+	}
+	b.WriteByte('\t')
+	b.WriteString(e.Message)
+	if len(e.Fields) > 0 {
+		b.WriteByte('\t')
+		b.WriteString(fieldsJSON(e.Fields))
+	}
+	b.WriteByte('\n')
+	if e.Stack != "" {
+		b.WriteString(e.Stack)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// fieldsJSON writes fields in the console encoder's style, with a space
+// after separators. The encoder keeps the order the fields were logged in,
+// which the decoded map has lost, so keys are sorted for stable output.
+func fieldsJSON(fields map[string]interface{}) string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range sortedKeys(fields) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		kb, _ := json.Marshal(k)
+		b.Write(kb)
+		b.WriteString(": ")
+		vb, err := json.Marshal(fields[k])
+		if err != nil {
+			vb = []byte(`"?"`)
+		}
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
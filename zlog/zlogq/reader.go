@@ -0,0 +1,303 @@
+package zlogq
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxLineSize bounds a single log line.
+const maxLineSize = 16 * 1024 * 1024
+
+// Files returns path together with its rotated backups, oldest first.
+// For app.log it finds backups written by zlog's size- and time-based
+// rotation, such as app-2026-10-17T15-04-05.000.log.gz or
+// app-2026-10-17.1.log. path itself need not exist. Backups are ordered by
+// the time stamp and sequence number in their names, since compression
+// does not keep the modification time.
+func Files(path string) ([]string, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type file struct {
+		path string
+		t    time.Time
+		seq  int
+	}
+	var files []file
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == base {
+			continue
+		}
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".zst")
+		if !strings.HasPrefix(trimmed, prefix) || !strings.HasSuffix(trimmed, ext) {
+			continue
+		}
+		t, seq, ok := parseBackupStamp(strings.TrimSuffix(strings.TrimPrefix(trimmed, prefix), ext))
+		if !ok {
+			continue
+		}
+		files = append(files, file{filepath.Join(dir, name), t, seq})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if !files[i].t.Equal(files[j].t) {
+			return files[i].t.Before(files[j].t)
+		}
+		if files[i].seq != files[j].seq {
+			return files[i].seq < files[j].seq
+		}
+		return files[i].path < files[j].path
+	})
+
+	paths := make([]string, 0, len(files)+1)
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// Layouts of the time stamps zlog and lumberjack put in backup names.
+var backupLayouts = []string{
+	"2006-01-02T15-04-05.000", // size-based rotation
+	"2006-01-02-15",           // hourly
+	"2006-01-02",              // daily
+}
+
+// parseBackupStamp parses the part of a backup name between the prefix and
+// the extension, such as 2026-10-17.2, into its time and sequence number.
+func parseBackupStamp(stamp string) (time.Time, int, bool) {
+	seq := 0
+	if i := strings.LastIndexByte(stamp, '.'); i >= 0 && !strings.Contains(stamp, "T") {
+		n, err := strconv.Atoi(stamp[i+1:])
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		stamp, seq = stamp[:i], n
+	}
+	for _, layout := range backupLayouts {
+		if len(stamp) != len(layout) {
+			continue
+		}
+		if t, err := time.Parse(layout, stamp); err == nil {
+			return t, seq, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// Open opens a log file, decompressing .gz and .zst files transparently.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return readCloser{zr, func() error { zr.Close(); return f.Close() }}, nil
+	case strings.HasSuffix(path, ".zst"):
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return readCloser{zr, func() error { zr.Close(); return f.Close() }}, nil
+	}
+	return f, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error { return r.close() }
+
+// Scan reads entries from r and calls fn for each one matching filter.
+// Lines that are not JSON entries are skipped. Returning io.EOF from fn
+// stops the scan without error.
+func Scan(r io.Reader, filter *Filter, fn func(*Entry) error) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineSize)
+	for sc.Scan() {
+		e, err := filter.parse(sc.Bytes())
+		if err != nil || !filter.Match(e) {
+			continue
+		}
+		if err := fn(e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// ReadFiles scans the given files in order.
+func ReadFiles(ctx context.Context, paths []string, filter *Filter, fn func(*Entry) error) error {
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rc, err := Open(p)
+		if err != nil {
+			return err
+		}
+		err = Scan(rc, filter, func(e *Entry) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(e)
+		})
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Follow calls fn for entries appended to path until ctx is done, like
+// tail -f. It starts at the end of the file unless fromStart is set, and
+// reopens the file when it is rotated or truncated. When path is the base
+// name of a time-rotated log (app.log written as app-2026-10-17.log), the
+// newest dated file is followed and Follow switches to the next one.
+func Follow(ctx context.Context, path string, fromStart bool, filter *Filter, fn func(*Entry) error) error {
+	const poll = 250 * time.Millisecond
+
+	var (
+		f       *os.File
+		current string
+		offset  int64
+		partial []byte
+	)
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	for first := true; ; first = false {
+		target := followTarget(path)
+		if f == nil || target != current || rotated(f, target, offset) {
+			if f != nil {
+				// Drain what was written before the switch.
+				if err := readNew(f, &partial, filter, fn); err != nil {
+					return err
+				}
+				f.Close()
+				f = nil
+			}
+			nf, err := os.Open(target)
+			if err == nil {
+				f, current, offset, partial = nf, target, 0, nil
+				if first && !fromStart {
+					offset, _ = f.Seek(0, io.SeekEnd)
+				}
+			}
+		}
+		if f != nil {
+			if err := readNew(f, &partial, filter, fn); err != nil {
+				return err
+			}
+			offset, _ = f.Seek(0, io.SeekCurrent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(poll):
+		}
+	}
+}
+
+// followTarget returns path if it exists, otherwise the newest uncompressed
+// rotated file belonging to it.
+func followTarget(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	files, err := Files(path)
+	if err != nil {
+		return path
+	}
+	for i := len(files) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(files[i], ".gz") && !strings.HasSuffix(files[i], ".zst") {
+			return files[i]
+		}
+	}
+	return path
+}
+
+// rotated reports whether the open file f no longer is the file at path or
+// was truncated below offset.
+func rotated(f *os.File, path string, offset int64) bool {
+	open, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	cur, err := os.Stat(path)
+	if err != nil {
+		return false // not recreated yet; keep reading the old file
+	}
+	return !os.SameFile(open, cur) || cur.Size() < offset
+}
+
+// readNew reads complete lines appended to f, keeping an unfinished last
+// line in partial for the next call.
+func readNew(f *os.File, partial *[]byte, filter *Filter, fn func(*Entry) error) error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			data := append(*partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				if e, perr := filter.parse(data[:i]); perr == nil && filter.Match(e) {
+					if ferr := fn(e); ferr != nil {
+						return ferr
+					}
+				}
+				data = data[i+1:]
+			}
+			*partial = append([]byte(nil), data...)
+		}
+		if err == io.EOF || n == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
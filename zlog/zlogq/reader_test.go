@@ -0,0 +1,53 @@
+package zlogq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFilesOrderedByName(t *testing.T) {
+	dir := t.TempDir()
+	// Oldest first; modification times are set in reverse, as when older
+	// backups are compressed after newer ones were written.
+	backups := []string{
+		"app-2026-10-16.log.zst",
+		"app-2026-10-17.log.gz",
+		"app-2026-10-17.1.log.gz",
+		"app-2026-10-17.2.log",
+		"app-2026-10-17.10.log",
+		"app-2026-10-17T09-00-00.000.log.gz",
+		"app-2026-10-17T18-30-00.000.log",
+	}
+	mtime := time.Now()
+	for _, name := range backups {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime = mtime.Add(-time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"app.log", "app-notes.log", "other-2026-10-17.log", "app-2026-10-17.x.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Files(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append(backups, "app.log")
+	if len(got) != len(want) {
+		t.Fatalf("Files = %v", got)
+	}
+	for i := range want {
+		if filepath.Base(got[i]) != want[i] {
+			t.Errorf("Files[%d] = %s, want %s", i, filepath.Base(got[i]), want[i])
+		}
+	}
+}
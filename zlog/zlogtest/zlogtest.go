@@ -0,0 +1,231 @@
+// Package zlogtest captures zlog entries in memory so tests can assert on
+// them, including fields added by hooks, redaction and *Ctx extraction.
+//
+//	func TestCharge(t *testing.T) {
+//		logs := zlogtest.ReplaceGlobals(t)
+//		charge(ctx)
+//		if logs.FilterMessage("charged").FilterField(zlog.String("request_id", "r1")).Len() != 1 {
+//			t.Fatal("missing log entry")
+//		}
+//	}
+package zlogtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is a captured log entry.
+type Entry struct {
+	Level      zlog.Level
+	Time       time.Time
+	LoggerName string
+	Message    string
+	Caller     zapcore.EntryCaller
+	Stack      string
+	Fields     []zlog.Field // fields added by With and at the call site, in order
+}
+
+// ContextMap returns the entry's fields as a map, nested objects included.
+func (e Entry) ContextMap() map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// Logs is a concurrency-safe collection of captured entries.
+type Logs struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func (o *Logs) add(e Entry) {
+	o.mu.Lock()
+	o.entries = append(o.entries, e)
+	o.mu.Unlock()
+}
+
+// Len returns the number of captured entries.
+func (o *Logs) Len() int {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return len(o.entries)
+}
+
+// All returns a copy of the captured entries.
+func (o *Logs) All() []Entry {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]Entry(nil), o.entries...)
+}
+
+// TakeAll returns the captured entries and clears the collection.
+func (o *Logs) TakeAll() []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	entries := o.entries
+	o.entries = nil
+	return entries
+}
+
+// Messages returns the messages of the captured entries.
+func (o *Logs) Messages() []string {
+	entries := o.All()
+	msgs := make([]string, len(entries))
+	for i, e := range entries {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+// Filter returns the entries for which keep returns true.
+func (o *Logs) Filter(keep func(Entry) bool) *Logs {
+	filtered := &Logs{}
+	for _, e := range o.All() {
+		if keep(e) {
+			filtered.entries = append(filtered.entries, e)
+		}
+	}
+	return filtered
+}
+
+// FilterLevel returns the entries logged at exactly level.
+func (o *Logs) FilterLevel(level zlog.Level) *Logs {
+	return o.Filter(func(e Entry) bool { return e.Level == level })
+}
+
+// FilterMessage returns the entries with message msg.
+func (o *Logs) FilterMessage(msg string) *Logs {
+	return o.Filter(func(e Entry) bool { return e.Message == msg })
+}
+
+// FilterMessageSnippet returns the entries whose message contains snippet.
+func (o *Logs) FilterMessageSnippet(snippet string) *Logs {
+	return o.Filter(func(e Entry) bool { return strings.Contains(e.Message, snippet) })
+}
+
+// FilterField returns the entries that contain field with an equal value.
+func (o *Logs) FilterField(field zlog.Field) *Logs {
+	return o.Filter(func(e Entry) bool {
+		for _, f := range e.Fields {
+			if f.Equals(field) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterFieldKey returns the entries that contain a field named key.
+func (o *Logs) FilterFieldKey(key string) *Logs {
+	return o.Filter(func(e Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NewCore returns a core that records every entry at or above level into logs.
+func NewCore(level zlog.Level) (zapcore.Core, *Logs) {
+	lvl, err := zapcore.ParseLevel(string(level))
+	if err != nil {
+		lvl = zapcore.DebugLevel
+	}
+	logs := &Logs{}
+	return &observerCore{LevelEnabler: lvl, logs: logs}, logs
+}
+
+type observerCore struct {
+	zapcore.LevelEnabler
+	logs   *Logs
+	fields []zlog.Field
+}
+
+func (c *observerCore) With(fields []zlog.Field) zapcore.Core {
+	return &observerCore{
+		LevelEnabler: c.LevelEnabler,
+		logs:         c.logs,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *observerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *observerCore) Write(ent zapcore.Entry, fields []zlog.Field) error {
+	all := make([]zlog.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+	c.logs.add(Entry{
+		Level:      zlog.Level(ent.Level.String()),
+		Time:       ent.Time,
+		LoggerName: ent.LoggerName,
+		Message:    ent.Message,
+		Caller:     ent.Caller,
+		Stack:      ent.Stack,
+		Fields:     all,
+	})
+	return nil
+}
+
+func (c *observerCore) Sync() error { return nil }
+
+// Option configures the observed logger.
+type Option func(*options)
+
+type options struct {
+	level  zlog.Level
+	config zlog.LoggerConfig
+}
+
+// WithLevel sets the lowest captured level; the default is debug.
+func WithLevel(level zlog.Level) Option {
+	return func(o *options) { o.level = level }
+}
+
+// WithConfig applies config (redaction, sampling, fields, span events) to
+// the observed logger. Its outputs are ignored.
+func WithConfig(config zlog.LoggerConfig) Option {
+	return func(o *options) { o.config = config }
+}
+
+// New returns a Logger whose entries are captured in the returned Logs.
+func New(t testing.TB, opts ...Option) (*zlog.Logger, *Logs) {
+	t.Helper()
+	o := options{level: zlog.DebugLevel}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	core, logs := NewCore(o.level)
+	logger, err := zlog.NewWithCoreConfig(core, o.config)
+	if err != nil {
+		t.Fatalf("zlogtest: %v", err)
+	}
+	t.Cleanup(func() { _ = logger.Close() })
+	return logger, logs
+}
+
+// ReplaceGlobals installs an observed logger as the zlog default, so the
+// package-level functions are captured, and restores the previous default
+// when the test finishes.
+func ReplaceGlobals(t testing.TB, opts ...Option) *Logs {
+	t.Helper()
+	logger, logs := New(t, opts...)
+	restore := zlog.ReplaceGlobals(logger)
+	t.Cleanup(restore)
+	return logs
+}
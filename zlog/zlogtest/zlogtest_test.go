@@ -0,0 +1,118 @@
+package zlogtest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chenzanhong/goutil/zlog"
+	"github.com/chenzanhong/goutil/zlog/zlogtest"
+)
+
+func TestReplaceGlobalsRestoresDefault(t *testing.T) {
+	before := zlog.L()
+	t.Run("captured", func(t *testing.T) {
+		logs := zlogtest.ReplaceGlobals(t)
+		if zlog.L() == before {
+			t.Fatal("ReplaceGlobals did not install the observed logger")
+		}
+		zlog.Info("package-level", zlog.String("k", "v"))
+		zlog.Named("payments").Warn("named")
+		if got := logs.Messages(); len(got) != 2 || got[0] != "package-level" || got[1] != "named" {
+			t.Fatalf("captured %v", got)
+		}
+		if e := logs.All()[1]; e.LoggerName != "payments" || e.Level != zlog.WarnLevel {
+			t.Errorf("named entry = %+v", e)
+		}
+		if e := logs.All()[0]; e.Caller.File == "" || e.Caller.Function == "" {
+			t.Errorf("caller not captured: %+v", e.Caller)
+		}
+	})
+	if zlog.L() != before {
+		t.Fatal("default instance not restored after the test finished")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	logger, logs := zlogtest.New(t)
+	logger.Debug("cache miss", zlog.String("key", "user:1"))
+	logger.Info("order created", zlog.String("order_id", "A1"), zlog.Int("items", 2))
+	logger.Warn("order slow", zlog.String("order_id", "A1"))
+	logger.With(zlog.String("order_id", "B2")).Error("order failed")
+
+	tests := []struct {
+		name string
+		got  *zlogtest.Logs
+		want []string
+	}{
+		{"level", logs.FilterLevel(zlog.WarnLevel), []string{"order slow"}},
+		{"message", logs.FilterMessage("order created"), []string{"order created"}},
+		{"snippet", logs.FilterMessageSnippet("order"), []string{"order created", "order slow", "order failed"}},
+		{"field", logs.FilterField(zlog.String("order_id", "A1")), []string{"order created", "order slow"}},
+		{"with field", logs.FilterField(zlog.String("order_id", "B2")), []string{"order failed"}},
+		{"field key", logs.FilterFieldKey("items"), []string{"order created"}},
+		{"chained", logs.FilterMessageSnippet("order").FilterLevel(zlog.ErrorLevel), []string{"order failed"}},
+		{"none", logs.FilterField(zlog.String("order_id", "C3")), nil},
+	}
+	for _, tt := range tests {
+		got := tt.got.Messages()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+
+	if m := logs.FilterMessage("order created").All()[0].ContextMap(); m["items"] != int64(2) || m["order_id"] != "A1" {
+		t.Errorf("ContextMap = %v", m)
+	}
+	if n := len(logs.TakeAll()); n != 4 || logs.Len() != 0 {
+		t.Errorf("TakeAll returned %d entries and left %d", n, logs.Len())
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	logger, logs := zlogtest.New(t, zlogtest.WithLevel(zlog.WarnLevel))
+	logger.Info("dropped")
+	logger.Warn("kept")
+	if got := logs.Messages(); len(got) != 1 || got[0] != "kept" {
+		t.Fatalf("captured %v", got)
+	}
+}
+
+func TestRedactionAndContext(t *testing.T) {
+	redact := zlog.DefaultRedactConfig()
+	logger, logs := zlogtest.New(t, zlogtest.WithConfig(zlog.LoggerConfig{
+		Redact: &redact,
+		Fields: map[string]string{"service": "shop"},
+	}))
+
+	ctx := context.WithValue(context.Background(), zlog.RequestIDKey, "req-7")
+	logger.InfoCtx(ctx, "login alice@example.com", zlog.String("password", "hunter2"))
+	logger.InfowCtx(ctx, "token issued", "token", "abc123")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("captured %d entries", len(entries))
+	}
+	if entries[0].Message != "login ******" {
+		t.Errorf("message not redacted: %q", entries[0].Message)
+	}
+	m := entries[0].ContextMap()
+	if m["password"] != "******" {
+		t.Errorf("password = %v", m["password"])
+	}
+	if m["request_id"] != "req-7" || m["service"] != "shop" {
+		t.Errorf("context or static fields missing: %v", m)
+	}
+	if m := entries[1].ContextMap(); m["token"] != "******" || m["request_id"] != "req-7" {
+		t.Errorf("sugared ctx entry = %v", m)
+	}
+	if logs.FilterField(zlog.String("request_id", "req-7")).Len() != 2 {
+		t.Error("FilterField does not see fields added from the context")
+	}
+}